@@ -0,0 +1,127 @@
+package e2e
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lib/standardlis2a2"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+)
+
+type CompositeResult struct {
+	SequenceNumber  int                             `astm:"2,sequence"`
+	UniversalTestID standardlis2a2.TestID           `astm:"3"`
+	Value           standardlis2a2.MeasurementValue `astm:"4"`
+	Units           string                          `astm:"5"`
+	Operator        standardlis2a2.OperatorID       `astm:"11"`
+}
+
+type CompositePatient struct {
+	SequenceNumber int                         `astm:"2,sequence"`
+	Name           standardlis2a2.PersonName   `astm:"6"`
+	Physicians     []standardlis2a2.PersonName `astm:"14"`
+}
+
+type CompositeMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Patient    CompositePatient          `astm:"P"`
+	Result     CompositeResult           `astm:"R"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestUnmarshalComponentStructs(t *testing.T) {
+	data := "H|\\^&|||\r" +
+		"P|1||||Doe^John^^^Dr||||||||Who^Doctor\\House^Gregory\r" +
+		"R|1|^^^SARS-CoV-2^IgG|6.77^^7|U/ml||||||lalina^kontrolla\r" +
+		"L|1|N\r"
+
+	var msg CompositeMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, standardlis2a2.PersonName{LastName: "Doe", FirstName: "John", Title: "Dr"}, msg.Patient.Name)
+	assert.Equal(t, []standardlis2a2.PersonName{{LastName: "Who", FirstName: "Doctor"}, {LastName: "House", FirstName: "Gregory"}}, msg.Patient.Physicians)
+	assert.Equal(t, "SARS-CoV-2", msg.Result.UniversalTestID.ManufacturersTestType)
+	assert.Equal(t, "IgG", msg.Result.UniversalTestID.ManufacturersTestName)
+	assert.Equal(t, "6.77", msg.Result.Value.DataMeasurementValue)
+	assert.Equal(t, "7", msg.Result.Value.MeasurementValueOfDevice)
+	assert.Equal(t, standardlis2a2.OperatorID{Performed: "lalina", Verified: "kontrolla"}, msg.Result.Operator)
+
+	// the components are all mapped, nothing is reported in strict mode
+	err = lis2a2.UnmarshalWithConfig([]byte(data), &msg, lis2a2.Config{Strict: true})
+	assert.Nil(t, err)
+}
+
+func TestMarshalComponentStructs(t *testing.T) {
+	var msg CompositeMessage
+	msg.Patient.Name = standardlis2a2.PersonName{LastName: "Doe", FirstName: "Jo^hn"}
+	msg.Patient.Physicians = []standardlis2a2.PersonName{{LastName: "Who"}, {LastName: "House", FirstName: "Gregory"}}
+	msg.Result.UniversalTestID = standardlis2a2.TestID{ManufacturersTestType: "SARS-CoV-2", ManufacturersTestName: "IgG"}
+	msg.Result.Operator = standardlis2a2.OperatorID{Performed: "lalina"}
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "P|1||||Doe^Jo&S&hn^^^||||||||Who^^^^\\House^Gregory^^^", string(lines[1]))
+	assert.Equal(t, "R|1|^^^SARS-CoV-2^IgG^^|^^|||||||lalina^", string(lines[2]))
+}
+
+type FarComponent struct {
+	Code  string `astm:"1"`
+	Extra string `astm:"150"`
+}
+
+type FarComponentMessage struct {
+	Header standardlis2a2.Header `astm:"H"`
+	Record struct {
+		SequenceNumber int          `astm:"2,sequence"`
+		Test           FarComponent `astm:"3"`
+	} `astm:"X"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestMarshalComponentBeyondHundred(t *testing.T) {
+	var msg FarComponentMessage
+	msg.Record.Test = FarComponent{Code: "A", Extra: "far"}
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "X|1|A"+strings.Repeat("^", 149)+"far", string(lines[1]))
+
+	var decoded FarComponentMessage
+	err = lis2a2.Unmarshal(bytes.Join(lines, []byte{'\r'}), &decoded, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, msg.Record.Test, decoded.Record.Test)
+}
+
+type RequiredComponent struct {
+	Code string    `astm:"1,require"`
+	Date time.Time `astm:"2"`
+}
+
+type RequiredComponentRecord struct {
+	SequenceNumber int               `astm:"2,sequence"`
+	Test           RequiredComponent `astm:"3"`
+}
+
+type RequiredComponentMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Record     RequiredComponentRecord   `astm:"X"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestUnmarshalComponentStructError(t *testing.T) {
+	data := "H|\\^&|||\rX|1|A^2022\rL|1|N\r"
+
+	var msg RequiredComponentMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.True(t, errors.Is(err, lis2a2.ErrInvalidValue))
+
+	var parseError *lis2a2.ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, 3, parseError.Field)
+	assert.Equal(t, 2, parseError.Component)
+	assert.Equal(t, "Test", parseError.FieldName)
+}
@@ -0,0 +1,172 @@
+package e2e
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lib/standardlis2a2"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+)
+
+type BloodGroup struct {
+	ABO    string
+	Rhesus bool
+}
+
+func (b *BloodGroup) UnmarshalASTM(value string) error {
+	switch {
+	case strings.HasSuffix(value, "+"):
+		b.ABO, b.Rhesus = strings.TrimSuffix(value, "+"), true
+	case strings.HasSuffix(value, "-"):
+		b.ABO, b.Rhesus = strings.TrimSuffix(value, "-"), false
+	default:
+		return errors.New("rhesus factor missing")
+	}
+	return nil
+}
+
+func (b BloodGroup) MarshalASTM() (string, error) {
+	if b.ABO == "" {
+		return "", nil
+	}
+	if b.Rhesus {
+		return b.ABO + "+", nil
+	}
+	return b.ABO + "-", nil
+}
+
+// SpecimenID only implements the encoding.Text interfaces
+type SpecimenID string
+
+func (s *SpecimenID) UnmarshalText(text []byte) error {
+	*s = SpecimenID(strings.TrimPrefix(string(text), "SID-"))
+	return nil
+}
+
+func (s SpecimenID) MarshalText() ([]byte, error) {
+	return []byte("SID-" + string(s)), nil
+}
+
+type CustomTypeRecord struct {
+	SequenceNumber int        `astm:"2,sequence"`
+	SpecimenID     SpecimenID `astm:"3.1"`
+	BloodGroup     BloodGroup `astm:"4"`
+}
+
+type CustomTypeMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Record     CustomTypeRecord          `astm:"X"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestUnmarshalCustomFieldTypes(t *testing.T) {
+	data := "H|\\^&|||\rX|1|SID-4711^A|AB-\rL|1|N\r"
+
+	var msg CustomTypeMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, SpecimenID("4711"), msg.Record.SpecimenID)
+	assert.Equal(t, BloodGroup{ABO: "AB", Rhesus: false}, msg.Record.BloodGroup)
+}
+
+func TestUnmarshalCustomFieldTypeError(t *testing.T) {
+	data := "H|\\^&|||\rX|1|SID-4711|AB\rL|1|N\r"
+
+	var msg CustomTypeMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.True(t, errors.Is(err, lis2a2.ErrInvalidValue))
+
+	var parseError *lis2a2.ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, 4, parseError.Field)
+	assert.Equal(t, "BloodGroup", parseError.FieldName)
+}
+
+func TestMarshalCustomFieldTypes(t *testing.T) {
+	var msg CustomTypeMessage
+	msg.Record.SpecimenID = "4711"
+	msg.Record.BloodGroup = BloodGroup{ABO: "0", Rhesus: true}
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "X|1|SID-4711|0+", string(lines[1]))
+}
+
+// ManufacturerRecord changes its layout with the second field
+type ManufacturerRecord struct {
+	Kind        string
+	Temperature string
+	Lot         string
+	Expiry      string
+}
+
+func (m *ManufacturerRecord) UnmarshalASTMRecord(record *lis2a2.Record) error {
+	m.Kind = record.Field(2).Value()
+	switch m.Kind {
+	case "TEMP":
+		m.Temperature = record.Field(3).Value()
+	case "REAGENT":
+		m.Lot = record.Field(3).Repeat(1).Component(1)
+		m.Expiry = record.Field(3).Repeat(1).Component(2)
+	default:
+		return fmt.Errorf("unknown layout %q", m.Kind)
+	}
+	return nil
+}
+
+func (m ManufacturerRecord) MarshalASTMRecord() (*lis2a2.Record, error) {
+	record := &lis2a2.Record{Fields: make([]lis2a2.Field, 3)}
+	record.Fields[1] = lis2a2.Field{Repeats: []lis2a2.Repeat{{Components: []string{m.Kind}}}}
+	switch m.Kind {
+	case "TEMP":
+		record.Fields[2] = lis2a2.Field{Repeats: []lis2a2.Repeat{{Components: []string{m.Temperature}}}}
+	case "REAGENT":
+		record.Fields[2] = lis2a2.Field{Repeats: []lis2a2.Repeat{{Components: []string{m.Lot, m.Expiry}}}}
+	}
+	return record, nil
+}
+
+type ManufacturerMessage struct {
+	Header       standardlis2a2.Header     `astm:"H"`
+	Manufacturer []ManufacturerRecord      `astm:"M"`
+	Terminator   standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestUnmarshalRecordHook(t *testing.T) {
+	data := "H|\\^&|||\rM|TEMP|37.1\rM|REAGENT|L0815^20231231\rL|1|N\r"
+
+	var msg ManufacturerMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(msg.Manufacturer))
+	assert.Equal(t, "37.1", msg.Manufacturer[0].Temperature)
+	assert.Equal(t, "L0815", msg.Manufacturer[1].Lot)
+	assert.Equal(t, "20231231", msg.Manufacturer[1].Expiry)
+	assert.Equal(t, "N", msg.Terminator.TerminatorCode)
+}
+
+func TestUnmarshalRecordHookError(t *testing.T) {
+	data := "H|\\^&|||\rM|UNKNOWN|1\rL|1|N\r"
+
+	var msg ManufacturerMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.True(t, errors.Is(err, lis2a2.ErrInvalidValue))
+
+	var parseError *lis2a2.ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, 2, parseError.Line)
+	assert.Equal(t, "M", parseError.RecordType)
+}
+
+func TestMarshalRecordHook(t *testing.T) {
+	var msg ManufacturerMessage
+	msg.Manufacturer = []ManufacturerRecord{{Kind: "TEMP", Temperature: "37.1"}, {Kind: "REAGENT", Lot: "L0815", Expiry: "20231231"}}
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "M|TEMP|37.1", string(lines[1]))
+	assert.Equal(t, "M|REAGENT|L0815^20231231", string(lines[2]))
+}
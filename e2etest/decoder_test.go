@@ -0,0 +1,123 @@
+package e2e
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lib/standardlis2a2"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestDecodeMessagesOneByOne(t *testing.T) {
+	var data string
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\r\n"
+	data = data + "P|1||DIA-01-085-7-1\r\n"
+	data = data + "O|1|||^^^SARSQVIGG3||20220715071219\r\n"
+	data = data + "R|1|^^^SARSQVIGG3|2598,88|BAU/ml|\r\n"
+	data = data + "L|1|N\r\n"
+	data = data + "\r\n"
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\r"
+	data = data + "P|1||DIA-01-056-7-1\r"
+	data = data + "O|1|||^^^SARSNCPIGG||20220715071219\r"
+	data = data + "R|1|^^^SARSNCPIGG|0,08|Ratio|\r"
+	data = data + "L|1|N"
+
+	decoder := lis2a2.NewDecoder(strings.NewReader(data), lis2a2.DefaultConfig)
+
+	var message standardlis2a2.DefaultMessage
+	assert.Nil(t, decoder.Decode(&message))
+	assert.Equal(t, "DIA-01-085-7-1", message.OrderResults[0].Patient.LabAssignedPatientID)
+	assert.Equal(t, "2598,88", message.OrderResults[0].CommentedResult[0].Result.DataMeasurementValue)
+
+	message = standardlis2a2.DefaultMessage{}
+	assert.Nil(t, decoder.Decode(&message))
+	assert.Equal(t, "DIA-01-056-7-1", message.OrderResults[0].Patient.LabAssignedPatientID)
+	assert.Equal(t, "0,08", message.OrderResults[0].CommentedResult[0].Result.DataMeasurementValue)
+
+	assert.Equal(t, io.EOF, decoder.Decode(&message))
+}
+
+func TestDecodeMessageWithoutLTerminator(t *testing.T) {
+	var data string
+	data = data + "H|\\^&|||\r"
+	data = data + "P|1||DIA-27-079-5-1\r"
+	data = data + "O|1|||^^^SARSCOV2IGA||20220218080737\r"
+	data = data + "H|\\^&|||\r"
+	data = data + "P|1||DIA-27-080-5-1\r"
+	data = data + "O|1|||^^^SARSCOV2IGA||20220218080737\r"
+	data = data + "L|1|N\r"
+
+	decoder := lis2a2.NewDecoder(strings.NewReader(data), lis2a2.DefaultConfig)
+
+	var message standardlis2a2.DefaultMessage
+	assert.NotNil(t, decoder.Decode(&message))
+
+	// the following message is not affected
+	message = standardlis2a2.DefaultMessage{}
+	assert.Nil(t, decoder.Decode(&message))
+	assert.Equal(t, "DIA-27-080-5-1", message.OrderResults[0].Patient.LabAssignedPatientID)
+
+	assert.Equal(t, io.EOF, decoder.Decode(&message))
+}
+
+func TestDecodeEncodedStream(t *testing.T) {
+	data := ""
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\n"
+	data = data + "P|1||1010868845||König^#$§?/+öäüß||19400607|M||||||||||||||||||||||||^\r"
+	data = data + "L|1|N\n"
+
+	encdata := helperEncode(charmap.Windows1252, []byte(data))
+	decoder := lis2a2.NewDecoder(strings.NewReader(string(encdata)), lis2a2.Config{
+		Encoding: lis2a2.EncodingWindows1252,
+		Timezone: lis2a2.TimezoneEuropeBerlin,
+	})
+
+	var message MessageGermanLanguageTest
+	assert.Nil(t, decoder.Decode(&message))
+	assert.Equal(t, "König", message.Patient.LastName)
+	assert.Equal(t, "#$§?/+öäüß", message.Patient.FirstName)
+}
+
+func TestDecodeBatchExportFromFile(t *testing.T) {
+	file, err := os.Open("../examples/euroimmun_analyzer1_v10/euroimmun/sampleigg.astm")
+	assert.Nil(t, err)
+	defer file.Close()
+
+	decoder := lis2a2.NewDecoder(file, lis2a2.DefaultConfig)
+
+	var message standardlis2a2.DefaultMessage
+	assert.Nil(t, decoder.Decode(&message))
+	assert.Equal(t, 20, len(message.OrderResults))
+	assert.Equal(t, "TEST-27-079-5-1", message.OrderResults[0].Patient.LabAssignedPatientID)
+	assert.Equal(t, ">8", message.OrderResults[0].CommentedResult[0].Result.DataMeasurementValue)
+
+	assert.Equal(t, io.EOF, decoder.Decode(&message))
+}
+
+func TestDecodeErrorLineInStream(t *testing.T) {
+	var data string
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\r\n"
+	data = data + "X|1|first||1.5\r\n"
+	data = data + "L|1|N\r\n"
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\r\n"
+	data = data + "X|1|second|^many|1.5\r\n"
+	data = data + "L|1|N\r\n"
+
+	decoder := lis2a2.NewDecoder(strings.NewReader(data), lis2a2.DefaultConfig)
+
+	var message ParseErrorMessage
+	err := decoder.Decode(&message)
+	assert.True(t, errors.Is(err, lis2a2.ErrMissingValue)) // Count is required
+
+	err = decoder.Decode(&message)
+	var parseError *lis2a2.ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.True(t, errors.Is(err, lis2a2.ErrInvalidValue))
+	assert.Equal(t, 5, parseError.Line)
+	assert.Equal(t, "Count", parseError.FieldName)
+}
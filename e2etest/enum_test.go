@@ -0,0 +1,69 @@
+package e2e
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lib/standardlis2a2"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+)
+
+type EnumResult struct {
+	SequenceNumber int                                 `astm:"2,sequence"`
+	TestCode       string                              `astm:"3.4"`
+	AbnormalFlags  []standardlis2a2.ResultAbnormalFlag `astm:"7"`
+	ResultStatus   standardlis2a2.ResultStatus         `astm:"9"`
+}
+
+type EnumMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Result     EnumResult                `astm:"R"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestUnmarshalEnums(t *testing.T) {
+	data := "H|\\^&|||\rR|1|^^^SARS||||HH\\<||F\rL|1|N\r"
+
+	var msg EnumMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, standardlis2a2.ResultStatusFinal, msg.Result.ResultStatus)
+	assert.Equal(t, []standardlis2a2.ResultAbnormalFlag{standardlis2a2.AbnormalFlagPanicHigh, standardlis2a2.AbnormalFlagBelowScale}, msg.Result.AbnormalFlags)
+}
+
+func TestUnmarshalEnumRejectsUnknownCode(t *testing.T) {
+	data := "H|\\^&|||\rR|1|^^^SARS||||H||Z\rL|1|N\r"
+
+	var msg EnumMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.True(t, errors.Is(err, lis2a2.ErrNotAllowedValue))
+
+	var parseError *lis2a2.ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, 2, parseError.Line)
+	assert.Equal(t, 9, parseError.Field)
+	assert.Equal(t, "ResultStatus", parseError.FieldName)
+
+	// lenient mode flags the code and goes on
+	err = lis2a2.UnmarshalWithConfig([]byte(data), &msg, lis2a2.Config{Lenient: true})
+	var parseErrors lis2a2.ParseErrors
+	assert.True(t, errors.As(err, &parseErrors))
+	assert.Equal(t, 1, len(parseErrors))
+	assert.Equal(t, standardlis2a2.ResultStatus(""), msg.Result.ResultStatus)
+	assert.Equal(t, "SARS", msg.Result.TestCode)
+	assert.Equal(t, "N", msg.Terminator.TerminatorCode)
+}
+
+func TestMarshalEnumRejectsUnknownCode(t *testing.T) {
+	var msg EnumMessage
+	msg.Result.ResultStatus = standardlis2a2.ResultStatusPreliminary
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "R|1|^^^||||||P", string(lines[1]))
+
+	msg.Result.ResultStatus = "Z"
+	_, err = lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.True(t, errors.Is(err, lis2a2.ErrNotAllowedValue))
+}
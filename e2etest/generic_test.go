@@ -0,0 +1,75 @@
+package e2e
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGenericMessage(t *testing.T) {
+	data := ""
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\r"
+	data = data + "P|1||1010868845||Testus^Test||19400607|M\r"
+	data = data + "O|1|1122206642|specimen1^^^\\specimen2^^^|^^^MO10^^28343^|R\r"
+	data = data + "C|1|I|Smith &E& Sons&S&Ltd|G\r"
+	data = data + "L|1|N\r"
+
+	messages, err := lis2a2.ParseMessages([]byte(data), lis2a2.DefaultConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(messages))
+
+	message := messages[0]
+	assert.Equal(t, lis2a2.DefaultDelimiters, message.Delimiters)
+	assert.Equal(t, 5, len(message.Records))
+
+	header := message.Records[0]
+	assert.Equal(t, "H", header.Type())
+	assert.Equal(t, "\\^&", header.Field(2).Value())
+	assert.Equal(t, "Bio-Rad", header.Field(5).Value())
+
+	patient := message.Records[1]
+	assert.Equal(t, "P", patient.Type())
+	assert.Equal(t, "Testus", patient.Field(6).Repeat(1).Component(1))
+	assert.Equal(t, "Test", patient.Field(6).Repeat(1).Component(2))
+	assert.Equal(t, "", patient.Field(6).Repeat(1).Component(3))
+	assert.Nil(t, patient.Field(42))
+	assert.Equal(t, "", patient.Field(42).Repeat(1).Component(1)) // absent parts read as empty
+
+	order := message.Records[2]
+	assert.Equal(t, 2, len(order.Field(4).Repeats))
+	assert.Equal(t, "specimen2", order.Field(4).Repeat(2).Component(1))
+	assert.Equal(t, "28343", order.Field(5).Repeat(1).Component(6))
+
+	comment := message.Records[3]
+	assert.Equal(t, "Smith & Sons^Ltd", comment.Field(4).Value())
+
+	// written back unchanged
+	var output bytes.Buffer
+	encoder := lis2a2.NewEncoder(&output, lis2a2.DefaultConfig)
+	assert.Nil(t, encoder.EncodeMessage(message))
+	assert.Equal(t, data, output.String())
+}
+
+func TestParseGenericMessagesWithCustomDelimiters(t *testing.T) {
+	data := ""
+	data = data + "H!~#$!!!first\n"
+	data = data + "P!1!!!!Testus#Test~Second#Name\n"
+	data = data + "L!1!N\n"
+	data = data + "H|\\^&|||second\n"
+	data = data + "L|1|N\n"
+
+	messages, err := lis2a2.ParseMessages([]byte(data), lis2a2.DefaultConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(messages))
+
+	assert.Equal(t, lis2a2.Delimiters{Field: "!", Repeat: "~", Component: "#", Escape: "$"}, messages[0].Delimiters)
+	patient := messages[0].Records[1]
+	assert.Equal(t, "Second", patient.Field(6).Repeat(2).Component(1))
+	assert.Equal(t, "second", messages[1].Records[0].Field(5).Value())
+
+	lines, err := lis2a2.MarshalMessage(messages[0], lis2a2.DefaultConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, "P!1!!!!Testus#Test~Second#Name", string(lines[1]))
+}
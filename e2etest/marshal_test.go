@@ -1,6 +1,7 @@
 package e2e
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 	"time"
@@ -323,3 +324,199 @@ func TestFailOnHeaderIsA_Reference(t *testing.T) {
 
 	assert.NotNil(t, err)
 }
+
+// Manufacturer has a string-typed sequence number, that must not fail marshalling
+func TestMarshalDefaultMessage(t *testing.T) {
+	var msg standardlis2a2.DefaultMessage
+	msg.OrderResults = make([]standardlis2a2.PORC, 1)
+	msg.OrderResults[0].Patient.LastName = "Testus"
+	msg.Terminator.TerminatorCode = "N"
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingASCII, lis2a2.TimezoneEuropeBerlin, lis2a2.ShortNotation)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "M|1||||||||||||", string(lines[1]))
+	assert.Equal(t, "L|1|N", string(lines[len(lines)-1]))
+}
+
+func TestEncodeWithLineBreaks(t *testing.T) {
+	var msg MinimalMessageMarshal
+	msg.Header.SenderNameOrID = "test"
+	msg.Ill.FirstFieldArray1 = "first"
+
+	var output bytes.Buffer
+	encoder := lis2a2.NewEncoder(&output, lis2a2.Config{
+		Encoding:  lis2a2.EncodingASCII,
+		Timezone:  lis2a2.TimezoneEuropeBerlin,
+		LineBreak: lis2a2.CRLF,
+	})
+	assert.Nil(t, encoder.Encode(msg))
+	assert.Equal(t, "H|\\^&|||test|||||||||\r\n?|1|first^^\\^|\r\nL|1|\r\n", output.String())
+
+	// CR is the default
+	output.Reset()
+	encoder = lis2a2.NewEncoder(&output, lis2a2.Config{Encoding: lis2a2.EncodingASCII, Timezone: lis2a2.TimezoneUTC})
+	assert.Nil(t, encoder.Encode(msg))
+	assert.Equal(t, "H|\\^&|||test|||||||||\r?|1|first^^\\^|\rL|1|\r", output.String())
+
+	output.Reset()
+	encoder = lis2a2.NewEncoder(&output, lis2a2.Config{Encoding: lis2a2.EncodingASCII, Timezone: lis2a2.TimezoneUTC, LineBreak: lis2a2.LF})
+	assert.Nil(t, encoder.Encode(msg))
+	assert.Equal(t, "H|\\^&|||test|||||||||\n?|1|first^^\\^|\nL|1|\n", output.String())
+}
+
+func TestEncodeWindows1252(t *testing.T) {
+	var msg ArrayMessageMarshal
+	msg.Patient = make([]standardlis2a2.Patient, 1)
+	msg.Patient[0].LastName = "König"
+
+	var output bytes.Buffer
+	encoder := lis2a2.NewEncoder(&output, lis2a2.Config{Encoding: lis2a2.EncodingWindows1252, Timezone: lis2a2.TimezoneUTC})
+	assert.Nil(t, encoder.Encode(msg))
+
+	// reading the output back yields the same message
+	decoder := lis2a2.NewDecoder(&output, lis2a2.Config{Encoding: lis2a2.EncodingWindows1252, Timezone: lis2a2.TimezoneUTC})
+	var decoded ArrayMessageMarshal
+	assert.Nil(t, decoder.Decode(&decoded))
+	assert.Equal(t, "König", decoded.Patient[0].LastName)
+}
+
+func TestMarshalWithCustomDelimiters(t *testing.T) {
+	var msg ArrayMessageMarshal
+	msg.Patient = make([]standardlis2a2.Patient, 1)
+	msg.Patient[0].LastName = "Testus"
+	msg.Patient[0].FirstName = "Test"
+
+	lines, err := lis2a2.MarshalWithConfig(msg, lis2a2.Config{
+		Encoding:   lis2a2.EncodingASCII,
+		Delimiters: lis2a2.Delimiters{Field: "!", Repeat: "~", Component: "^", Escape: "$"},
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, "H!~^$!!!!!!!!!!!!", string(lines[0]))
+	assert.Equal(t, "P!1!!!!Testus^Test", string(lines[1][:18]))
+	assert.Equal(t, "L!1!", string(lines[2]))
+}
+
+type EscapeRecord struct {
+	SequenceNumber int    `astm:"2,sequence"`
+	Name           string `astm:"3.1"`
+	Remark         string `astm:"3.2"`
+}
+
+type EscapeMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Record     EscapeRecord              `astm:"X"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestMarshalEscapesDelimiters(t *testing.T) {
+	var msg EscapeMessage
+	msg.Record.Name = "Smith & Sons"
+	msg.Record.Remark = "pH|value^high\\low"
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingASCII, lis2a2.TimezoneUTC, lis2a2.StandardNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "X|1|Smith &E& Sons^pH&F&value&S&high&R&low", string(lines[1]))
+
+	// reading it back yields the original values
+	var decoded EscapeMessage
+	err = lis2a2.Unmarshal(bytes.Join(lines, []byte{'\r'}), &decoded, lis2a2.EncodingASCII, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, msg.Record.Name, decoded.Record.Name)
+	assert.Equal(t, msg.Record.Remark, decoded.Record.Remark)
+}
+
+type EscapeFieldMessage struct {
+	Header standardlis2a2.Header `astm:"H"`
+	Record struct {
+		SequenceNumber int    `astm:"2,sequence"`
+		Name           string `astm:"3"`
+	} `astm:"X"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestMarshalEscapesWholeField(t *testing.T) {
+	var msg EscapeFieldMessage
+	msg.Record.Name = "a|b^c\\d&e"
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingASCII, lis2a2.TimezoneUTC, lis2a2.StandardNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "X|1|a&F&b&S&c&R&d&E&e", string(lines[1]))
+
+	var decoded EscapeFieldMessage
+	err = lis2a2.Unmarshal(bytes.Join(lines, []byte{'\r'}), &decoded, lis2a2.EncodingASCII, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, msg.Record.Name, decoded.Record.Name)
+}
+
+func TestMarshalAdoptsDelimitersFromHeader(t *testing.T) {
+	var msg EscapeMessage
+	msg.Header.Delimiters = "~#$"
+	msg.Record.Name = "A^B"
+	msg.Record.Remark = "C#D"
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingASCII, lis2a2.TimezoneUTC, lis2a2.StandardNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "H|~#$||||||||||||", string(lines[0]))
+	assert.Equal(t, "X|1|A^B#C$S$D", string(lines[1]))
+}
+
+type RawHeader struct {
+	Raw        string `astm:",raw"`
+	Delimiters string `astm:"2,delimiter"`
+	SenderName string `astm:"5.1"`
+}
+
+type RawPatient struct {
+	Raw            string    `astm:",raw"`
+	SequenceNumber int       `astm:"2,sequence"`
+	LastName       string    `astm:"6.1"`
+	FirstName      string    `astm:"6.2"`
+	DOB            time.Time `astm:"8"`
+}
+
+type RawMessage struct {
+	Header     RawHeader                 `astm:"H"`
+	Patient    RawPatient                `astm:"P"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestMarshalRawRecordIsLossless(t *testing.T) {
+	data := "H|\\^&|||Analyzer^1.0^SN42|||||||P|1\r" +
+		"P|1||PID-7&S&B||Doe^John^^^Dr|Maiden|19700101|M|||||||extra^value\r" +
+		"L|1|N\r"
+
+	var msg RawMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, "H|\\^&|||Analyzer^1.0^SN42|||||||P|1", msg.Header.Raw)
+	assert.Equal(t, "Doe", msg.Patient.LastName)
+
+	// unchanged records are reproduced byte for byte
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.StandardNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "H|\\^&|||Analyzer^1.0^SN42|||||||P|1", string(lines[0]))
+	assert.Equal(t, "P|1||PID-7&S&B||Doe^John^^^Dr|Maiden|19700101|M|||||||extra^value", string(lines[1]))
+
+	// only the changed component is replaced
+	msg.Patient.FirstName = "Jane"
+	msg.Patient.DOB = time.Date(1971, 2, 3, 0, 0, 0, 0, time.UTC)
+	lines, err = lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.StandardNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "P|1||PID-7&S&B||Doe^Jane^^^Dr|Maiden|19710203|M|||||||extra^value", string(lines[1]))
+}
+
+func TestMarshalRawRecordKeepsFieldDelimiter(t *testing.T) {
+	data := "H!~^$!!!Analyzer\rP!1!!!!Doe^John!!!unmapped\rL!1\r"
+
+	var msg RawMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+
+	msg.Patient.LastName = "Roe"
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.StandardNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "H!~^$!!!Analyzer", string(lines[0]))
+	assert.Equal(t, "P!1!!!!Roe^John!!!unmapped", string(lines[1]))
+}
@@ -0,0 +1,62 @@
+package e2e
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathAccess(t *testing.T) {
+	data := ""
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\r"
+	data = data + "P|1||1010868845||Testus^Test||19400607|M\r"
+	data = data + "O|1|1122206642|specimen1^^^\\specimen2^^^|^^^MO10^^28343^|R\r"
+	data = data + "R|1|^^^AntiA^MO10|40^^|C\r"
+	data = data + "R|2|^^^AntiB^MO10|0^^|C\r"
+	data = data + "R|3|^^^AntiD^MO10|0^^|C\r"
+	data = data + "L|1|N\r"
+
+	messages, err := lis2a2.ParseMessages([]byte(data), lis2a2.DefaultConfig)
+	assert.Nil(t, err)
+	message := messages[0]
+
+	value, err := message.Get("P.6.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Testus", value)
+
+	value, err = message.Get("R[2].3.4")
+	assert.Nil(t, err)
+	assert.Equal(t, "AntiB", value)
+
+	value, err = message.Get("O.4.2.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "specimen2", value)
+
+	value, err = message.Get("P.30")
+	assert.Nil(t, err)
+	assert.Equal(t, "", value)
+
+	_, err = message.Get("R[4].3.4")
+	assert.True(t, errors.Is(err, lis2a2.ErrPathNotFound))
+
+	values, err := message.GetAll("R.3.4")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"AntiA", "AntiB", "AntiD"}, values)
+
+	values, err = message.GetAll("R[3].4")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"0"}, values)
+
+	assert.Nil(t, message.Set("R[3].4.1", "12"))
+	assert.Nil(t, message.Set("P.6.3", "Middle"))
+	assert.Nil(t, message.Set("L.4.2.2", "x"))
+	assert.True(t, errors.Is(message.Set("R.1", "X"), lis2a2.ErrInvalidPath))
+
+	lines, err := lis2a2.MarshalMessage(message, lis2a2.DefaultConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, "P|1||1010868845||Testus^Test^Middle||19400607|M", string(lines[1]))
+	assert.Equal(t, "R|3|^^^AntiD^MO10|12^^|C", string(lines[5]))
+	assert.Equal(t, "L|1|N|\\^x", string(lines[6]))
+}
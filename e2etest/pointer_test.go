@@ -0,0 +1,112 @@
+package e2e
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lib/standardlis2a2"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+)
+
+type PointerPatient struct {
+	SequenceNumber *int                       `astm:"2,sequence"`
+	PatientID      *string                    `astm:"3"`
+	Name           *standardlis2a2.PersonName `astm:"6"`
+	DOB            *time.Time                 `astm:"8"`
+	Height         *float64                   `astm:"17"`
+}
+
+type PointerMessage struct {
+	Header       standardlis2a2.Header        `astm:"H"`
+	Manufacturer *standardlis2a2.Manufacturer `astm:"M,optional"`
+	Patient      *PointerPatient              `astm:"P"`
+	Comments     []*standardlis2a2.Comment    `astm:"C,optional"`
+	Terminator   standardlis2a2.Terminator    `astm:"L"`
+}
+
+func TestUnmarshalAbsentPointerRecord(t *testing.T) {
+	data := "H|\\^&|||\rP|1||||||||||||||||\rL|1|N\r"
+
+	var msg PointerMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Nil(t, msg.Manufacturer)
+	assert.Nil(t, msg.Comments)
+	assert.NotNil(t, msg.Patient)
+
+	// empty fields leave the pointers nil
+	assert.Equal(t, 1, *msg.Patient.SequenceNumber)
+	assert.Nil(t, msg.Patient.PatientID)
+	assert.Nil(t, msg.Patient.Name)
+	assert.Nil(t, msg.Patient.DOB)
+	assert.Nil(t, msg.Patient.Height)
+}
+
+func TestUnmarshalPresentPointerRecord(t *testing.T) {
+	data := "H|\\^&|||\rM|1|info\rP|1|4711|||Doe^John||19700101|||||||||172.5\rC|1|I|first\rC|2|I|second\rL|1|N\r"
+
+	var msg PointerMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.NotNil(t, msg.Manufacturer)
+	assert.Equal(t, "info", msg.Manufacturer.F2)
+	assert.Equal(t, "4711", *msg.Patient.PatientID)
+	assert.Equal(t, "Doe", msg.Patient.Name.LastName)
+	assert.Equal(t, time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), *msg.Patient.DOB)
+	assert.Equal(t, 172.5, *msg.Patient.Height)
+	assert.Equal(t, 2, len(msg.Comments))
+	assert.Equal(t, "second", msg.Comments[1].CommentText)
+}
+
+func TestMarshalPointers(t *testing.T) {
+	patientID := "4711"
+	var msg PointerMessage
+	msg.Patient = &PointerPatient{PatientID: &patientID}
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(lines)) // no M and C records
+	assert.Equal(t, "P|1|4711|||^^^^|||||||||||", string(lines[1]))
+	assert.Equal(t, "L|1|", string(lines[2]))
+}
+
+type PointerGroup struct {
+	Patient  PointerPatient            `astm:"P"`
+	Comments []*standardlis2a2.Comment `astm:"C,optional"`
+}
+
+type PointerGroupMessage struct {
+	Header     standardlis2a2.Header `astm:"H"`
+	Groups     []*PointerGroup
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestPointerGroupsRoundTrip(t *testing.T) {
+	data := "H|\\^&|||\rP|1|4711\rC|1|I|first\rP|2|4712\rL|1|N\r"
+
+	var msg PointerGroupMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(msg.Groups))
+	assert.Equal(t, "4711", *msg.Groups[0].Patient.PatientID)
+	assert.Equal(t, 1, len(msg.Groups[0].Comments))
+	assert.Equal(t, "first", msg.Groups[0].Comments[0].CommentText)
+	assert.Equal(t, "4712", *msg.Groups[1].Patient.PatientID)
+	assert.Nil(t, msg.Groups[1].Comments)
+
+	msg.Groups = append(msg.Groups, nil) // nil groups are not written
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(lines))
+	assert.Equal(t, "P|1|4711|||^^^^|||||||||||", string(lines[1]))
+	assert.Equal(t, "C|1|I|first|", string(lines[2]))
+	assert.Equal(t, "P|1|4712|||^^^^|||||||||||", string(lines[3])) // sequence numbers count within a group, as for []Group
+
+	var again PointerGroupMessage
+	err = lis2a2.Unmarshal(bytes.Join(lines, []byte{'\r'}), &again, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(again.Groups))
+	assert.Equal(t, "4712", *again.Groups[1].Patient.PatientID)
+}
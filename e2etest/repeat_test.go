@@ -0,0 +1,109 @@
+package e2e
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lib/standardlis2a2"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+)
+
+type RepeatOrder struct {
+	SequenceNumber int          `astm:"2,sequence"`
+	SpecimenIDs    []string     `astm:"3"`
+	Instruments    []string     `astm:"4.4"`
+	Tests          []string     `astm:"5.4"`
+	Collected      []time.Time  `astm:"8"`
+	BloodGroups    []BloodGroup `astm:"9"`
+}
+
+type RepeatMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Order      RepeatOrder               `astm:"O"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestUnmarshalRepeatsToSlices(t *testing.T) {
+	data := "H|\\^&|||\r" +
+		"O|1|4711\\4712\\4713|1122206642^^^A\\1122206642^^^B|^^^SARS\\^^^HIV|||20220101\\20220102|A+\\0-\r" +
+		"L|1|N\r"
+
+	var msg RepeatMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"4711", "4712", "4713"}, msg.Order.SpecimenIDs)
+	assert.Equal(t, []string{"A", "B"}, msg.Order.Instruments)
+	assert.Equal(t, []string{"SARS", "HIV"}, msg.Order.Tests)
+	assert.Equal(t, []time.Time{time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)}, msg.Order.Collected)
+	assert.Equal(t, []BloodGroup{{ABO: "A", Rhesus: true}, {ABO: "0", Rhesus: false}}, msg.Order.BloodGroups)
+}
+
+func TestUnmarshalEmptyRepeatField(t *testing.T) {
+	data := "H|\\^&|||\rO|1||||\rL|1|N\r"
+
+	var msg RepeatMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Nil(t, msg.Order.SpecimenIDs)
+	assert.Nil(t, msg.Order.Tests)
+}
+
+func TestUnmarshalRepeatsStrict(t *testing.T) {
+	data := "H|\\^&|||\rO|1|4711\\4712||^^^SARS\\^^^HIV\rL|1|N\r"
+
+	var msg RepeatMessage
+	err := lis2a2.UnmarshalWithConfig([]byte(data), &msg, lis2a2.Config{Strict: true})
+	assert.Nil(t, err)
+}
+
+func TestMarshalSlicesToRepeats(t *testing.T) {
+	var msg RepeatMessage
+	msg.Order.SpecimenIDs = []string{"4711", "47\\12"}
+	msg.Order.Tests = []string{"SARS", "HIV"}
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "O|1|4711\\47&R&12||^^^SARS\\^^^HIV", string(lines[1]))
+}
+
+func TestMarshalRepeatsRoundTrip(t *testing.T) {
+	var msg RepeatMessage
+	msg.Order.SpecimenIDs = []string{"r^1", "r\\2"}
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "O|1|r&S&1\\r&R&2", string(lines[1]))
+
+	var decoded RepeatMessage
+	err = lis2a2.Unmarshal(bytes.Join(lines, []byte{'\r'}), &decoded, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, msg.Order.SpecimenIDs, decoded.Order.SpecimenIDs)
+}
+
+type RawRepeatOrder struct {
+	Raw         string   `astm:",raw"`
+	SpecimenIDs []string `astm:"3"`
+	Tests       []string `astm:"5.4"`
+}
+
+type RawRepeatMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Order      RawRepeatOrder            `astm:"O"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestMarshalRawRecordWithChangedRepeats(t *testing.T) {
+	data := "H|\\^&|||\rO|1|A\\B\\C|x^y|^^^SARS^1\\^^^HIV^2|keep\rL|1|N\r"
+
+	var msg RawRepeatMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+
+	msg.Order.SpecimenIDs = []string{"A", "D"}
+	msg.Order.Tests = []string{"SARS", "HIV", "HCV"}
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.StandardNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "O|1|A\\D|x^y|^^^SARS^1\\^^^HIV^2\\^^^HCV|keep", string(lines[1]))
+}
@@ -0,0 +1,133 @@
+package e2e
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lib/standardlis2a2"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+)
+
+type ScalarRecord struct {
+	SequenceNumber uint16         `astm:"2,sequence"`
+	Confirmed      bool           `astm:"3"`
+	Repeated       bool           `astm:"4,true=1,false=0"`
+	Small          int8           `astm:"5"`
+	Big            int64          `astm:"6"`
+	Count          uint32         `astm:"7"`
+	Value          lis2a2.Decimal `astm:"8"`
+	Dilution       lis2a2.Decimal `astm:"9"`
+}
+
+type ScalarMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Record     ScalarRecord              `astm:"X"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestUnmarshalScalarTypes(t *testing.T) {
+	data := "H|\\^&|||\rX|1|Y|1|-12|9007199254740993|4000000000|7,41|0.0001\rL|1|N\r"
+
+	var msg ScalarMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(1), msg.Record.SequenceNumber)
+	assert.True(t, msg.Record.Confirmed)
+	assert.True(t, msg.Record.Repeated)
+	assert.Equal(t, int8(-12), msg.Record.Small)
+	assert.Equal(t, int64(9007199254740993), msg.Record.Big)
+	assert.Equal(t, uint32(4000000000), msg.Record.Count)
+	assert.Equal(t, lis2a2.Decimal("7,41"), msg.Record.Value)
+	assert.Equal(t, lis2a2.Decimal("0.0001"), msg.Record.Dilution)
+}
+
+func TestUnmarshalScalarTypeErrors(t *testing.T) {
+	var msg ScalarMessage
+
+	// out of range for int8 is only an error for required fields, like other invalid numbers
+	err := lis2a2.Unmarshal([]byte("H|\\^&|||\rX|1|N|0|300\rL|1|N\r"), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, int8(0), msg.Record.Small)
+
+	// the same for decimals, e.g. results with a qualifier
+	msg = ScalarMessage{}
+	err = lis2a2.Unmarshal([]byte("H|\\^&|||\rX|1|N|0|1|2|3|<0.1|>100000\rL|1|N\r"), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, lis2a2.Decimal(""), msg.Record.Value)
+	assert.Equal(t, lis2a2.Decimal(""), msg.Record.Dilution)
+
+	var required struct {
+		Header standardlis2a2.Header `astm:"H"`
+		Record struct {
+			Value lis2a2.Decimal `astm:"3,require"`
+		} `astm:"X"`
+		Terminator standardlis2a2.Terminator `astm:"L"`
+	}
+	err = lis2a2.Unmarshal([]byte("H|\\^&|||\rX|1|<0.1\rL|1|N\r"), &required, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.True(t, errors.Is(err, lis2a2.ErrInvalidValue))
+}
+
+func TestMarshalScalarTypes(t *testing.T) {
+	var msg ScalarMessage
+	msg.Record = ScalarRecord{
+		Confirmed: true,
+		Small:     -12,
+		Big:       9007199254740993,
+		Count:     4000000000,
+		Value:     "7,41",
+		Dilution:  "0.0001",
+	}
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "X|1|Y|0|-12|9007199254740993|4000000000|7,41|0.0001", string(lines[1]))
+}
+
+type FloatRecord struct {
+	SequenceNumber int     `astm:"2,sequence"`
+	Single         float32 `astm:"3"`
+	Double         float64 `astm:"4,precision=2"`
+	Comma          float64 `astm:"5,precision=2,decimal=comma"`
+	Shortest       float64 `astm:"6,precision=-1"`
+}
+
+type FloatMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Record     FloatRecord               `astm:"X"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestMarshalFloats(t *testing.T) {
+	var msg FloatMessage
+	msg.Record = FloatRecord{Single: 1.5, Double: 3.14159, Comma: 6.77, Shortest: 0.0001}
+
+	lines, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+	assert.Equal(t, "X|1|1.500|3.14|6,77|0.0001", string(lines[1]))
+}
+
+func TestUnmarshalFloatWithDecimalComma(t *testing.T) {
+	data := "H|\\^&|||\rX|1|2.25|3.5|6,77|1e-3\rL|1|N\r"
+
+	var msg FloatMessage
+	err := lis2a2.Unmarshal([]byte(data), &msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC)
+	assert.Nil(t, err)
+	assert.Equal(t, float32(2.25), msg.Record.Single)
+	assert.Equal(t, 3.5, msg.Record.Double)
+	assert.Equal(t, 6.77, msg.Record.Comma)
+	assert.Equal(t, 0.001, msg.Record.Shortest)
+}
+
+type InvalidPrecisionRecord struct {
+	Value float64 `astm:"3,precision=two"`
+}
+
+func TestMarshalInvalidPrecision(t *testing.T) {
+	msg := struct {
+		Record InvalidPrecisionRecord `astm:"X"`
+	}{}
+
+	_, err := lis2a2.Marshal(msg, lis2a2.EncodingUTF8, lis2a2.TimezoneUTC, lis2a2.ShortNotation)
+	assert.True(t, errors.Is(err, lis2a2.ErrInvalidAnnotation))
+}
@@ -2,6 +2,8 @@ package e2e
 
 import (
 	"bytes"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -572,3 +574,274 @@ func TestMultipleMessagesInOne(t *testing.T) {
 	assert.Equal(t, "12,5", message.Messages[0].OrderResults[0].CommentedResult[0].Result.DataMeasurementValue)
 	assert.Equal(t, "99,66", message.Messages[1].OrderResults[0].CommentedResult[0].Result.DataMeasurementValue)
 }
+
+type SimplePatientMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Patient    standardlis2a2.Patient    `astm:"P"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+// the delimiters are taken from the header, the timezone is given as *time.Location
+func TestUnmarshalWithConfig(t *testing.T) {
+	data := ""
+	data = data + "H!~^$!!!Bio-Rad!IH v5.2!!!!!!!!20220315194227\r"
+	data = data + "P!1!!1010868845!!Testus^Test~Second^Name!!19400607!M\r"
+	data = data + "L!1!N\r"
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.Nil(t, err)
+
+	var message SimplePatientMessage
+	err = lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{Location: berlin})
+	assert.Nil(t, err)
+
+	assert.Equal(t, "Bio-Rad", message.Header.SenderNameOrID)
+	assert.Equal(t, "Testus", message.Patient.LastName)
+	assert.Equal(t, "Test", message.Patient.FirstName)
+	assert.Equal(t, "20220315184227", message.Header.DateAndTime.UTC().Format("20060102150405"))
+}
+
+type ParseErrorRecord struct {
+	SequenceNumber int     `astm:"2,sequence"`
+	Name           string  `astm:"3"`
+	Count          int     `astm:"4.2,require"`
+	Value          float64 `astm:"5"`
+}
+
+type ParseErrorMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Record     ParseErrorRecord          `astm:"X"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	data := ""
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\n"
+	data = data + "\n"
+	data = data + "X|1|name|unit^many|2.5\n"
+	data = data + "L|1|N\n"
+
+	var message ParseErrorMessage
+	err := lis2a2.Unmarshal([]byte(data), &message, lis2a2.EncodingUTF8, lis2a2.TimezoneEuropeBerlin)
+
+	var parseError *lis2a2.ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.True(t, errors.Is(err, lis2a2.ErrInvalidValue))
+	assert.Equal(t, 3, parseError.Line) // empty lines are counted
+	assert.Equal(t, "X", parseError.RecordType)
+	assert.Equal(t, 4, parseError.Field)
+	assert.Equal(t, 1, parseError.Repeat)
+	assert.Equal(t, 2, parseError.Component)
+	assert.Equal(t, "Count", parseError.FieldName)
+	assert.Equal(t, "unit^many", parseError.Value)
+}
+
+func TestParseErrorUnexpectedRecord(t *testing.T) {
+	data := ""
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\r"
+	data = data + "P|1||1010868845\r"
+	data = data + "L|1|N\r"
+
+	var message ParseErrorMessage
+	err := lis2a2.Unmarshal([]byte(data), &message, lis2a2.EncodingUTF8, lis2a2.TimezoneEuropeBerlin)
+
+	var parseError *lis2a2.ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.True(t, errors.Is(err, lis2a2.ErrUnexpectedRecord))
+	assert.Equal(t, 2, parseError.Line)
+	assert.Equal(t, "P", parseError.RecordType)
+	assert.Equal(t, "P|1||1010868845", parseError.Value)
+}
+
+func TestParseErrorSkippedInput(t *testing.T) {
+	data := ""
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\r"
+	data = data + "L|1|N\r"
+	data = data + "P|1||1010868845\r"
+	data = data + "O|1|1122206642\r"
+
+	var message MinimalMessage
+	err := lis2a2.Unmarshal([]byte(data), &message, lis2a2.EncodingUTF8, lis2a2.TimezoneEuropeBerlin)
+
+	var parseError *lis2a2.ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.True(t, errors.Is(err, lis2a2.ErrSkippedInput))
+	assert.Equal(t, 3, parseError.Line)
+	assert.Equal(t, "P", parseError.RecordType)
+}
+
+func TestLenientUnmarshalCollectsErrors(t *testing.T) {
+	data := ""
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\r"
+	data = data + "P|1||1010868845||Testus^Test||1940XX07|M\r"
+	data = data + "O|1|1122206642||^^^MO10|R\r"
+	data = data + "R|1|^^^AntiA|40|C||||R||lalina|2022031111410\r"
+	data = data + "Q|1|^1010868845||ALL\r"
+	data = data + "P|2||1010868846||Testis^Tost||19400607|M\r"
+	data = data + "R|1|^^^AntiB|0|C||||R||lalina|20220311114103\r"
+	data = data + "L|1|N\r"
+
+	var message standardlis2a2.DefaultMessage
+	err := lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{Lenient: true})
+
+	var parseErrors lis2a2.ParseErrors
+	assert.True(t, errors.As(err, &parseErrors))
+	assert.Equal(t, 4, len(parseErrors))
+
+	// invalid date of birth
+	assert.Equal(t, 2, parseErrors[0].Line)
+	assert.Equal(t, "DOB", parseErrors[0].FieldName)
+	assert.True(t, errors.Is(parseErrors[0], lis2a2.ErrInvalidValue))
+	// invalid date and time of the first result
+	assert.Equal(t, 4, parseErrors[1].Line)
+	assert.Equal(t, "DateTimeTestStarted", parseErrors[1].FieldName)
+	// the query record does not belong into the message
+	assert.Equal(t, 5, parseErrors[2].Line)
+	assert.Equal(t, "Q", parseErrors[2].RecordType)
+	assert.True(t, errors.Is(parseErrors[2], lis2a2.ErrUnexpectedRecord))
+	// the second patient has no order
+	assert.Equal(t, 7, parseErrors[3].Line)
+	assert.True(t, errors.Is(parseErrors[3], lis2a2.ErrUnexpectedRecord))
+
+	assert.True(t, errors.Is(err, lis2a2.ErrInvalidValue))
+
+	// everything else was read
+	assert.Equal(t, 2, len(message.OrderResults))
+	assert.Equal(t, "Testus", message.OrderResults[0].Patient.LastName)
+	assert.True(t, message.OrderResults[0].Patient.DOB.IsZero())
+	assert.Equal(t, "40", message.OrderResults[0].CommentedResult[0].Result.DataMeasurementValue)
+	assert.Equal(t, "Testis", message.OrderResults[1].Patient.LastName)
+	assert.Equal(t, "0", message.OrderResults[1].CommentedResult[0].Result.DataMeasurementValue)
+	assert.Equal(t, "N", message.Terminator.TerminatorCode)
+
+	// without lenient mode the first error aborts
+	err = lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{})
+	assert.False(t, errors.As(err, &parseErrors))
+	assert.True(t, errors.Is(err, lis2a2.ErrInvalidValue))
+}
+
+func TestLenientUnmarshalWithoutTerminator(t *testing.T) {
+	data := ""
+	data = data + "H|\\^&|||\r"
+	data = data + "P|1||DIA-27-079-5-1\r"
+	data = data + "O|1|||^^^SARSCOV2IGA||20220218080737\r"
+
+	var message standardlis2a2.DefaultMessage
+	err := lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{Lenient: true})
+	assert.True(t, errors.Is(err, lis2a2.ErrPrematureEnd))
+	assert.Equal(t, "DIA-27-079-5-1", message.OrderResults[0].Patient.LabAssignedPatientID)
+}
+
+func TestLenientUnmarshalOnlyUnknownRecords(t *testing.T) {
+	data := "Q|1|a\rZ|2\r"
+
+	var message standardlis2a2.DefaultMessage
+	err := lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{Lenient: true})
+
+	// the unknown records are reported together with the missing input
+	var parseErrors lis2a2.ParseErrors
+	assert.True(t, errors.As(err, &parseErrors))
+	assert.Equal(t, 3, len(parseErrors))
+	assert.True(t, errors.Is(err, lis2a2.ErrNoInput))
+	assert.True(t, errors.Is(err, lis2a2.ErrUnexpectedRecord))
+	assert.Equal(t, "Q", parseErrors[1].RecordType)
+	assert.Equal(t, 1, parseErrors[1].Line)
+	assert.Equal(t, "Z", parseErrors[2].RecordType)
+	assert.Equal(t, 2, parseErrors[2].Line)
+}
+
+func TestStrictUnmarshal(t *testing.T) {
+	data := ""
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\r"
+	data = data + "P|1||1010868845||Testus^Test||19400607|M\r"
+	data = data + "L|1|N\r"
+
+	var message SimplePatientMessage
+	err := lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{Strict: true})
+	assert.Nil(t, err)
+
+	// a third component of the name is not mapped by standardlis2a2.Patient
+	unmapped := strings.Replace(data, "Testus^Test", "Testus^Test^Middle", 1)
+	err = lis2a2.UnmarshalWithConfig([]byte(unmapped), &message, lis2a2.Config{Strict: true})
+	var parseError *lis2a2.ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.True(t, errors.Is(err, lis2a2.ErrUnmappedValue))
+	assert.Equal(t, 2, parseError.Line)
+	assert.Equal(t, "P", parseError.RecordType)
+	assert.Equal(t, 6, parseError.Field)
+	assert.Equal(t, 3, parseError.Component)
+	assert.Equal(t, "Middle", parseError.Value)
+
+	// bestfit without strict mode
+	err = lis2a2.UnmarshalWithConfig([]byte(unmapped), &message, lis2a2.Config{})
+	assert.Nil(t, err)
+
+	// unknown records are rejected, even if they would not have been in the way
+	unknown := strings.Replace(data, "L|1|N", "C|1|I|comment\rL|1|N", 1)
+	err = lis2a2.UnmarshalWithConfig([]byte(unknown), &message, lis2a2.Config{Strict: true})
+	assert.True(t, errors.As(err, &parseError))
+	assert.True(t, errors.Is(err, lis2a2.ErrUnexpectedRecord))
+	assert.Equal(t, 3, parseError.Line)
+}
+
+func TestStrictUnmarshalRequiredField(t *testing.T) {
+	data := ""
+	data = data + "H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227\r"
+	data = data + "X|1|name\r"
+	data = data + "L|1|N\r"
+
+	var message ParseErrorMessage
+	err := lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{})
+	assert.Nil(t, err) // the record ends before the required field
+
+	err = lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{Strict: true})
+	var parseError *lis2a2.ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.True(t, errors.Is(err, lis2a2.ErrMissingValue))
+	assert.Equal(t, "Count", parseError.FieldName)
+	assert.Equal(t, 2, parseError.Line)
+
+	// strict and lenient together report all problems
+	data = data + "Y|1\r"
+	err = lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{Strict: true, Lenient: true})
+	var parseErrors lis2a2.ParseErrors
+	assert.True(t, errors.As(err, &parseErrors))
+	assert.Equal(t, 2, len(parseErrors))
+	assert.Equal(t, "name", message.Record.Name)
+}
+
+type EmptyRequiredRecord struct {
+	SequenceNumber int               `astm:"2,sequence"`
+	Name           string            `astm:"3,require"`
+	Test           RequiredComponent `astm:"4"`
+}
+
+type EmptyRequiredMessage struct {
+	Header     standardlis2a2.Header     `astm:"H"`
+	Record     EmptyRequiredRecord       `astm:"X"`
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestStrictUnmarshalEmptyRequiredField(t *testing.T) {
+	data := "H|\\^&|||\rX|1||SARS^20220101\rL|1|N\r"
+
+	var message EmptyRequiredMessage
+	err := lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{})
+	assert.Nil(t, err) // empty fields are accepted unless strict
+
+	err = lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{Strict: true})
+	var parseError *lis2a2.ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.True(t, errors.Is(err, lis2a2.ErrMissingValue))
+	assert.Equal(t, "Name", parseError.FieldName)
+	assert.Equal(t, 3, parseError.Field)
+
+	// the same for a required component of a component struct
+	data = "H|\\^&|||\rX|1|name|^20220101\rL|1|N\r"
+	err = lis2a2.UnmarshalWithConfig([]byte(data), &message, lis2a2.Config{Strict: true})
+	assert.True(t, errors.As(err, &parseError))
+	assert.True(t, errors.Is(err, lis2a2.ErrMissingValue))
+	assert.Equal(t, "Test", parseError.FieldName)
+	assert.Equal(t, 4, parseError.Field)
+	assert.Equal(t, 1, parseError.Component)
+}
@@ -5,11 +5,11 @@ go 1.17
 require (
 	github.com/aglyzov/charmap v0.0.0-20151220132847-945fb53710f2
 	github.com/stretchr/testify v1.7.1
+	golang.org/x/text v0.3.7
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/text v0.3.7 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
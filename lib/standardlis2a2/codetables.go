@@ -0,0 +1,171 @@
+package standardlis2a2
+
+// Code tables of LIS2-A2 as enums, a field of such a type only accepts the listed codes (see lis2a2.Enum).
+// The record types of this package keep plain strings, as instruments often send their own codes.
+
+// ProcessingID - 6.12
+type ProcessingID string
+
+const (
+	ProcessingIDProduction     ProcessingID = "P"
+	ProcessingIDTraining       ProcessingID = "T"
+	ProcessingIDDebugging      ProcessingID = "D"
+	ProcessingIDQualityControl ProcessingID = "Q"
+)
+
+func (ProcessingID) AllowedValues() []string {
+	return []string{"P", "T", "D", "Q"}
+}
+
+// Sex - 7.9
+type Sex string
+
+const (
+	SexMale    Sex = "M"
+	SexFemale  Sex = "F"
+	SexUnknown Sex = "U"
+)
+
+func (Sex) AllowedValues() []string {
+	return []string{"M", "F", "U"}
+}
+
+// Priority - 8.4.6
+type Priority string
+
+const (
+	PriorityStat         Priority = "S"
+	PriorityASAP         Priority = "A"
+	PriorityRoutine      Priority = "R"
+	PriorityCallback     Priority = "C"
+	PriorityPreoperative Priority = "P"
+)
+
+func (Priority) AllowedValues() []string {
+	return []string{"S", "A", "R", "C", "P"}
+}
+
+// ActionCode - 8.4.12
+type ActionCode string
+
+const (
+	ActionCodeCancel         ActionCode = "C"
+	ActionCodeAdd            ActionCode = "A"
+	ActionCodeNew            ActionCode = "N"
+	ActionCodePending        ActionCode = "P"
+	ActionCodeReserved       ActionCode = "L"
+	ActionCodeInProcess      ActionCode = "X"
+	ActionCodeQualityControl ActionCode = "Q"
+)
+
+func (ActionCode) AllowedValues() []string {
+	return []string{"C", "A", "N", "P", "L", "X", "Q"}
+}
+
+// ReportType - 8.4.26
+type ReportType string
+
+const (
+	ReportTypeOrder             ReportType = "O"
+	ReportTypeCorrection        ReportType = "C"
+	ReportTypePreliminary       ReportType = "P"
+	ReportTypeFinal             ReportType = "F"
+	ReportTypeCannotBeDone      ReportType = "X"
+	ReportTypeInstrumentPending ReportType = "I"
+	ReportTypeNoOrder           ReportType = "Y"
+	ReportTypeNoRecord          ReportType = "Z"
+	ReportTypeQueryResponse     ReportType = "Q"
+)
+
+func (ReportType) AllowedValues() []string {
+	return []string{"O", "C", "P", "F", "X", "I", "Y", "Z", "Q"}
+}
+
+// ResultAbnormalFlag - 9.7
+type ResultAbnormalFlag string
+
+const (
+	AbnormalFlagLow             ResultAbnormalFlag = "L"
+	AbnormalFlagHigh            ResultAbnormalFlag = "H"
+	AbnormalFlagPanicLow        ResultAbnormalFlag = "LL"
+	AbnormalFlagPanicHigh       ResultAbnormalFlag = "HH"
+	AbnormalFlagBelowScale      ResultAbnormalFlag = "<"
+	AbnormalFlagAboveScale      ResultAbnormalFlag = ">"
+	AbnormalFlagNormal          ResultAbnormalFlag = "N"
+	AbnormalFlagAbnormal        ResultAbnormalFlag = "A"
+	AbnormalFlagSignificantUp   ResultAbnormalFlag = "U"
+	AbnormalFlagSignificantDown ResultAbnormalFlag = "D"
+	AbnormalFlagBetter          ResultAbnormalFlag = "B"
+	AbnormalFlagWorse           ResultAbnormalFlag = "W"
+)
+
+func (ResultAbnormalFlag) AllowedValues() []string {
+	return []string{"L", "H", "LL", "HH", "<", ">", "N", "A", "U", "D", "B", "W"}
+}
+
+// ResultStatus - 9.9
+type ResultStatus string
+
+const (
+	ResultStatusCorrection     ResultStatus = "C"
+	ResultStatusPreliminary    ResultStatus = "P"
+	ResultStatusFinal          ResultStatus = "F"
+	ResultStatusCannotBeDone   ResultStatus = "X"
+	ResultStatusPending        ResultStatus = "I"
+	ResultStatusPartial        ResultStatus = "S"
+	ResultStatusMIC            ResultStatus = "M"
+	ResultStatusPreviouslySent ResultStatus = "R"
+	ResultStatusNewInformation ResultStatus = "N"
+	ResultStatusQueryResponse  ResultStatus = "Q"
+	ResultStatusApproved       ResultStatus = "V"
+	ResultStatusWorklist       ResultStatus = "W"
+)
+
+func (ResultStatus) AllowedValues() []string {
+	return []string{"C", "P", "F", "X", "I", "S", "M", "R", "N", "Q", "V", "W"}
+}
+
+// CommentSource - 10.3
+type CommentSource string
+
+const (
+	CommentSourcePractice   CommentSource = "P"
+	CommentSourceLIS        CommentSource = "L"
+	CommentSourceInstrument CommentSource = "I"
+)
+
+func (CommentSource) AllowedValues() []string {
+	return []string{"P", "L", "I"}
+}
+
+// CommentType - 10.5
+type CommentType string
+
+const (
+	CommentTypeGeneric        CommentType = "G"
+	CommentTypeTestName       CommentType = "T"
+	CommentTypePositive       CommentType = "P"
+	CommentTypeNegative       CommentType = "N"
+	CommentTypeInstrumentFlag CommentType = "I"
+)
+
+func (CommentType) AllowedValues() []string {
+	return []string{"G", "T", "P", "N", "I"}
+}
+
+// TerminationCode - 12.3
+type TerminationCode string
+
+const (
+	TerminationNormal               TerminationCode = "N"
+	TerminationSenderAborted        TerminationCode = "T"
+	TerminationReceiverAborted      TerminationCode = "R"
+	TerminationUnknownError         TerminationCode = "E"
+	TerminationQueryError           TerminationCode = "Q"
+	TerminationNoInformation        TerminationCode = "I"
+	TerminationLastRequestProcessed TerminationCode = "F"
+)
+
+func (TerminationCode) AllowedValues() []string {
+	return []string{"N", "T", "R", "E", "Q", "I", "F"}
+}
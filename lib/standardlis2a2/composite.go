@@ -0,0 +1,37 @@
+package standardlis2a2
+
+// Composite field types, their members are the components of a field. Use them in own record
+// definitions, e.g. `UniversalTestID TestID astm:"3"`, or as slices to read all repeats.
+
+// PersonName - last^first^middle^suffix^title as in the patient name (7.6) and physician names
+type PersonName struct {
+	LastName   string `astm:"1"`
+	FirstName  string `astm:"2"`
+	MiddleName string `astm:"3"`
+	Suffix     string `astm:"4"`
+	Title      string `astm:"5"`
+}
+
+// TestID - the universal test id of orders (8.4.5), results (9.3) and requests (11.5)
+type TestID struct {
+	UniversalTestID       string `astm:"1"`
+	UniversalTestIDName   string `astm:"2"`
+	UniversalTestIDType   string `astm:"3"`
+	ManufacturersTestType string `astm:"4"`
+	ManufacturersTestName string `astm:"5"`
+	ManufacturersTestCode string `astm:"6"`
+	TestCode              string `astm:"7"`
+}
+
+// MeasurementValue - the value of a result (9.4)
+type MeasurementValue struct {
+	DataMeasurementValue     string `astm:"1"`
+	InitialMeasurementValue  string `astm:"2"`
+	MeasurementValueOfDevice string `astm:"3"`
+}
+
+// OperatorID - the operators who performed and verified a result (9.11)
+type OperatorID struct {
+	Performed string `astm:"1"`
+	Verified  string `astm:"2"`
+}
@@ -0,0 +1,22 @@
+package lis1a
+
+// Transmission control characters (see LIS1-A / ASTM E1381 Section 6)
+const (
+	STX byte = 0x02 // start of frame
+	ETX byte = 0x03 // end of an end-frame
+	EOT byte = 0x04 // end of transmission
+	ENQ byte = 0x05 // request to establish the link
+	ACK byte = 0x06 // positive acknowledgement
+	NAK byte = 0x15 // negative acknowledgement
+	ETB byte = 0x17 // end of an intermediate frame
+	CR  byte = 0x0D
+	LF  byte = 0x0A
+)
+
+// Frame numbers run from 0 to 7, the first frame of a transmission is numbered 1
+const FRAME_NUMBER_MODULO = 8
+const FIRST_FRAME_NUMBER = 1
+
+// A frame must not exceed 247 characters: STX, FN, 240 characters of text, ETB/ETX, C1, C2, CR, LF
+const MAX_FRAME_SIZE = 247
+const MAX_FRAME_TEXT_LENGTH = 240
@@ -0,0 +1,186 @@
+package lis1a
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrFrameFormat   = errors.New("invalid frame format")
+	ErrChecksum      = errors.New("frame checksum mismatch")
+	ErrFrameSequence = errors.New("frame number out of sequence")
+)
+
+// Frame is one LIS1-A frame: <STX> FN text <ETX|ETB> C1 C2 <CR> <LF>
+type Frame struct {
+	Number int    // frame number 0-7
+	Text   []byte // for end-frames this includes the CR terminating the record
+	Final  bool   // true for end-frames (ETX), false for intermediate frames (ETB)
+}
+
+// Checksum calculates the two hex-digit checksum over the characters from
+// the frame number up to and including the ETX/ETB
+func Checksum(data []byte) string {
+	var sum byte
+	for _, b := range data {
+		sum += b
+	}
+	return fmt.Sprintf("%02X", sum)
+}
+
+// Bytes renders the frame for the wire including checksum and the trailing CR LF
+func (f Frame) Bytes() []byte {
+	body := make([]byte, 0, len(f.Text)+2)
+	body = append(body, byte('0'+f.Number%FRAME_NUMBER_MODULO))
+	body = append(body, f.Text...)
+	if f.Final {
+		body = append(body, ETX)
+	} else {
+		body = append(body, ETB)
+	}
+
+	out := make([]byte, 0, len(body)+5)
+	out = append(out, STX)
+	out = append(out, body...)
+	out = append(out, Checksum(body)...)
+	out = append(out, CR, LF)
+	return out
+}
+
+// ParseFrame validates a frame as received from the wire (STX up to and including CR LF)
+func ParseFrame(raw []byte) (Frame, error) {
+	// STX FN ETX C1 C2 CR LF is the shortest possible frame
+	if len(raw) < 7 {
+		return Frame{}, fmt.Errorf("%w: frame too short (%d bytes)", ErrFrameFormat, len(raw))
+	}
+	if raw[0] != STX {
+		return Frame{}, fmt.Errorf("%w: frame does not start with STX", ErrFrameFormat)
+	}
+	if raw[len(raw)-2] != CR || raw[len(raw)-1] != LF {
+		return Frame{}, fmt.Errorf("%w: frame does not end with CR LF", ErrFrameFormat)
+	}
+
+	terminator := raw[len(raw)-5]
+	if terminator != ETX && terminator != ETB {
+		return Frame{}, fmt.Errorf("%w: missing ETX/ETB", ErrFrameFormat)
+	}
+
+	fn := raw[1]
+	if fn < '0' || fn > '7' {
+		return Frame{}, fmt.Errorf("%w: invalid frame number '%c'", ErrFrameFormat, fn)
+	}
+
+	body := raw[1 : len(raw)-4]
+	received := string(raw[len(raw)-4 : len(raw)-2])
+	if expected := Checksum(body); !strings.EqualFold(expected, received) {
+		return Frame{}, fmt.Errorf("%w: expected %s, received %s", ErrChecksum, expected, received)
+	}
+
+	return Frame{
+		Number: int(fn - '0'),
+		Text:   append([]byte{}, raw[2:len(raw)-5]...),
+		Final:  terminator == ETX,
+	}, nil
+}
+
+// EncodeRecords wraps the records of a message (as produced by lis2a2.Marshal) into
+// numbered frames, one record per frame. Numbering starts with 1 as required for
+// the first frame after establishment.
+func EncodeRecords(records [][]byte) [][]byte {
+	return EncodeRecordsWithLimit(records, 0)
+}
+
+// EncodeRecordsWithLimit works like EncodeRecords, but records whose text (including the
+// terminating CR) exceeds maxTextLength are split into intermediate frames (ETB) followed
+// by an end-frame (ETX). A maxTextLength of 0 disables splitting; LIS1-A compliant
+// receivers expect at most MAX_FRAME_TEXT_LENGTH.
+func EncodeRecordsWithLimit(records [][]byte, maxTextLength int) [][]byte {
+	frames := make([][]byte, 0, len(records))
+	frameNumber := FIRST_FRAME_NUMBER
+	for _, record := range records {
+		text := make([]byte, 0, len(record)+1)
+		text = append(text, record...)
+		text = append(text, CR)
+
+		for maxTextLength > 0 && len(text) > maxTextLength {
+			frame := Frame{Number: frameNumber, Text: text[:maxTextLength], Final: false}
+			frames = append(frames, frame.Bytes())
+			frameNumber = (frameNumber + 1) % FRAME_NUMBER_MODULO
+			text = text[maxTextLength:]
+		}
+
+		frame := Frame{Number: frameNumber, Text: text, Final: true}
+		frames = append(frames, frame.Bytes())
+		frameNumber = (frameNumber + 1) % FRAME_NUMBER_MODULO
+	}
+	return frames
+}
+
+// Assembler receives the frames of one transmission in order and returns the records.
+// Intermediate frames are joined with the following frames until the end-frame completes the record.
+type Assembler struct {
+	expectedFrameNumber int
+	pending             []byte
+}
+
+func NewAssembler() *Assembler {
+	return &Assembler{expectedFrameNumber: FIRST_FRAME_NUMBER}
+}
+
+// Add validates a frame as received from the wire. Once an end-frame completes a record
+// the record (without the terminating CR) is returned and complete is true. Frames with a
+// bad checksum or an unexpected frame number are rejected and do not advance the sequence.
+func (a *Assembler) Add(raw []byte) (record []byte, complete bool, err error) {
+	frame, err := ParseFrame(raw)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if frame.Number != a.expectedFrameNumber {
+		return nil, false, fmt.Errorf("%w: expected %d, received %d", ErrFrameSequence, a.expectedFrameNumber, frame.Number)
+	}
+	a.expectedFrameNumber = (a.expectedFrameNumber + 1) % FRAME_NUMBER_MODULO
+
+	a.pending = append(a.pending, frame.Text...)
+	if !frame.Final {
+		return nil, false, nil
+	}
+
+	record = bytes.TrimRight(a.pending, string([]byte{CR}))
+	a.pending = nil
+	return record, true, nil
+}
+
+// Incomplete is true if intermediate frames were received that still wait for their end-frame
+func (a *Assembler) Incomplete() bool {
+	return len(a.pending) > 0
+}
+
+// Reset restarts the frame numbering and drops incomplete records, e.g. after a new link establishment
+func (a *Assembler) Reset() {
+	a.expectedFrameNumber = FIRST_FRAME_NUMBER
+	a.pending = nil
+}
+
+// DecodeFrames turns the frames of a transmission back into the record lines,
+// separated by CR, as they are expected by lis2a2.Unmarshal
+func DecodeFrames(frames [][]byte) ([]byte, error) {
+	assembler := NewAssembler()
+	var message []byte
+	for i, raw := range frames {
+		record, complete, err := assembler.Add(raw)
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %w", i+1, err)
+		}
+		if complete {
+			message = append(message, record...)
+			message = append(message, CR)
+		}
+	}
+	if assembler.Incomplete() {
+		return nil, fmt.Errorf("%w: transmission ends with an intermediate frame", ErrFrameFormat)
+	}
+	return message, nil
+}
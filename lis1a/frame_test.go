@@ -0,0 +1,116 @@
+package lis1a
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lib/standardlis2a2"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameChecksum(t *testing.T) {
+	frame := Frame{Number: 1, Text: []byte("H|\\^&|||Bio-Rad\r"), Final: true}
+
+	assert.Equal(t, "\x021H|\\^&|||Bio-Rad\r\x03B7\r\n", string(frame.Bytes()))
+
+	parsed, err := ParseFrame(frame.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, frame, parsed)
+}
+
+func TestFrameRejectsBadChecksum(t *testing.T) {
+	raw := []byte("\x021H|\\^&|||Bio-Rad\r\x03B8\r\n")
+
+	_, err := ParseFrame(raw)
+	assert.True(t, errors.Is(err, ErrChecksum))
+}
+
+func TestFrameRejectsOutOfSequence(t *testing.T) {
+	frames := EncodeRecords([][]byte{[]byte("H|\\^&"), []byte("L|1|N")})
+
+	assembler := NewAssembler()
+	_, _, err := assembler.Add(frames[1])
+	assert.True(t, errors.Is(err, ErrFrameSequence))
+
+	record, complete, err := assembler.Add(frames[0])
+	assert.Nil(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, "H|\\^&", string(record))
+}
+
+func TestFrameNumbersWrapAround(t *testing.T) {
+	records := make([][]byte, 10)
+	for i := range records {
+		records[i] = []byte("C|1|")
+	}
+	frames := EncodeRecords(records)
+
+	assert.Equal(t, byte('1'), frames[0][1])
+	assert.Equal(t, byte('7'), frames[6][1])
+	assert.Equal(t, byte('0'), frames[7][1])
+	assert.Equal(t, byte('1'), frames[8][1])
+
+	_, err := DecodeFrames(frames)
+	assert.Nil(t, err)
+}
+
+type framedMessage struct {
+	Header       standardlis2a2.Header `astm:"H"`
+	OrderResults []standardlis2a2.PORC
+	Terminator   standardlis2a2.Terminator `astm:"L"`
+}
+
+func TestFramedRoundTrip(t *testing.T) {
+	var msg framedMessage
+	msg.Header.SenderNameOrID = "LIS"
+	msg.OrderResults = make([]standardlis2a2.PORC, 1)
+	msg.OrderResults[0].Patient.LastName = "Testus"
+	msg.OrderResults[0].Order.SpecimenID = "1122206642"
+	msg.Terminator.TerminatorCode = "N"
+
+	records, err := lis2a2.Marshal(msg, lis2a2.EncodingASCII, lis2a2.TimezoneEuropeBerlin, lis2a2.ShortNotation)
+	assert.Nil(t, err)
+
+	frames := EncodeRecords(records)
+	assert.Equal(t, len(records), len(frames))
+
+	message, err := DecodeFrames(frames)
+	assert.Nil(t, err)
+
+	var decoded framedMessage
+	err = lis2a2.Unmarshal(message, &decoded, lis2a2.EncodingASCII, lis2a2.TimezoneEuropeBerlin)
+	assert.Nil(t, err)
+	assert.Equal(t, "LIS", decoded.Header.SenderNameOrID)
+	assert.Equal(t, "Testus", decoded.OrderResults[0].Patient.LastName)
+	assert.Equal(t, "1122206642", decoded.OrderResults[0].Order.SpecimenID)
+}
+
+func TestSplitLongRecordIntoIntermediateFrames(t *testing.T) {
+	longComment := "C|1|I|" + strings.Repeat("Anti-D weak positive, repeat with new sample. ", 12) + "|G"
+	records := [][]byte{[]byte("H|\\^&"), []byte(longComment), []byte("L|1|N")}
+
+	frames := EncodeRecordsWithLimit(records, MAX_FRAME_TEXT_LENGTH)
+	assert.Equal(t, 5, len(frames))
+
+	for _, frame := range frames {
+		assert.LessOrEqual(t, len(frame), MAX_FRAME_SIZE)
+	}
+	assert.Equal(t, ETX, frames[0][len(frames[0])-5])
+	assert.Equal(t, ETB, frames[1][len(frames[1])-5])
+	assert.Equal(t, ETB, frames[2][len(frames[2])-5])
+	assert.Equal(t, ETX, frames[3][len(frames[3])-5])
+
+	message, err := DecodeFrames(frames)
+	assert.Nil(t, err)
+	assert.Equal(t, "H|\\^&\r"+longComment+"\rL|1|N\r", string(message))
+}
+
+func TestIncompleteRecordIsRejected(t *testing.T) {
+	longComment := "C|1|I|" + strings.Repeat("x", 300) + "|G"
+	frames := EncodeRecordsWithLimit([][]byte{[]byte(longComment)}, MAX_FRAME_TEXT_LENGTH)
+
+	_, err := DecodeFrames(frames[:1])
+	assert.True(t, errors.Is(err, ErrFrameFormat))
+}
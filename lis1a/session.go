@@ -0,0 +1,432 @@
+package lis1a
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTimeout                = errors.New("timeout waiting for the other side")
+	ErrLinkBusy               = errors.New("receiver is busy (establishment was rejected)")
+	ErrTooManyRetransmissions = errors.New("frame was rejected too often, transmission aborted")
+	ErrSessionClosed          = errors.New("session is closed")
+)
+
+// Role decides who wins when both sides try to establish the link at the same time (contention)
+type Role int
+
+const RoleComputer Role = 1   // the LIS; yields to the instrument on contention
+const RoleInstrument Role = 2 // the analyzer; always wins on contention
+
+// after six unsuccessful attempts to send a frame the transmission is aborted
+const MAX_RETRANSMISSIONS = 6
+
+// a received frame larger than this is discarded (LIS1-A allows 247, some instruments send more)
+const MAX_RECEIVE_FRAME_SIZE = 64 * 1024
+
+// Timeouts of the LIS1-A link layer (see Section 8.5). Zero values are replaced by the defaults.
+type Timeouts struct {
+	Acknowledge time.Duration // sender waiting for a reply to ENQ or a frame (15s)
+	Receive     time.Duration // receiver waiting for the next frame or EOT (30s)
+	Busy        time.Duration // sender waiting after the receiver answered ENQ with NAK (10s)
+	Contention  time.Duration // waiting after contention: 20s for the computer, 1s for the instrument
+}
+
+type SessionConfig struct {
+	Role          Role
+	Timeouts      Timeouts
+	MaxTextLength int // records are split into intermediate frames beyond this length (default MAX_FRAME_TEXT_LENGTH)
+}
+
+// MessageHandler receives a complete transmission; the records are separated by CR,
+// ready to be passed to lis2a2.Unmarshal
+type MessageHandler func(message []byte)
+
+// Session implements the establishment, transfer and termination phases of LIS1-A on any
+// io.ReadWriter (TCP connection, serial line, ...). Run has to be running for messages to be
+// received and for Send to work.
+type Session struct {
+	rw       io.ReadWriter
+	handler  MessageHandler
+	config   SessionConfig
+	input    chan byte
+	readErr  error
+	requests chan *sendRequest
+	done     chan struct{}
+	runOnce  sync.Once
+}
+
+// FrameResult reports how the transfer of one frame went
+type FrameResult struct {
+	Number       int  // frame number 0-7
+	Attempts     int  // how often the frame was sent
+	Acknowledged bool // the receiver accepted the frame
+}
+
+// TransferReport lists the frames of a transmission in the order they were sent.
+// Frames after a failed one are not listed as they have never been sent.
+type TransferReport struct {
+	Frames []FrameResult
+}
+
+type sendRequest struct {
+	records [][]byte
+	result  chan sendResult
+}
+
+type sendResult struct {
+	report TransferReport
+	err    error
+}
+
+func NewSession(rw io.ReadWriter, handler MessageHandler, config SessionConfig) *Session {
+	if config.Role == 0 {
+		config.Role = RoleComputer
+	}
+	if config.Timeouts.Acknowledge == 0 {
+		config.Timeouts.Acknowledge = 15 * time.Second
+	}
+	if config.Timeouts.Receive == 0 {
+		config.Timeouts.Receive = 30 * time.Second
+	}
+	if config.Timeouts.Busy == 0 {
+		config.Timeouts.Busy = 10 * time.Second
+	}
+	if config.Timeouts.Contention == 0 {
+		if config.Role == RoleInstrument {
+			config.Timeouts.Contention = 1 * time.Second
+		} else {
+			config.Timeouts.Contention = 20 * time.Second
+		}
+	}
+	if config.MaxTextLength == 0 {
+		config.MaxTextLength = MAX_FRAME_TEXT_LENGTH
+	}
+
+	return &Session{
+		rw:       rw,
+		handler:  handler,
+		config:   config,
+		input:    make(chan byte, MAX_FRAME_SIZE),
+		requests: make(chan *sendRequest),
+		done:     make(chan struct{}),
+	}
+}
+
+// Run processes the link until reading or writing fails (e.g. io.EOF when the connection is closed).
+// Received messages are passed to the handler from within Run, so the handler blocks the link.
+func (s *Session) Run() error {
+	err := ErrSessionClosed
+	s.runOnce.Do(func() {
+		defer close(s.done)
+		go s.readLoop()
+		err = s.neutral()
+	})
+	return err
+}
+
+// Send transmits the records (e.g. as returned by lis2a2.Marshal) as one message.
+// It blocks until the receiver acknowledged the last frame or the transmission was aborted.
+func (s *Session) Send(records [][]byte) error {
+	_, err := s.Transfer(records)
+	return err
+}
+
+// Transfer works like Send and additionally reports the acknowledgement of every frame
+func (s *Session) Transfer(records [][]byte) (TransferReport, error) {
+	request := &sendRequest{records: records, result: make(chan sendResult, 1)}
+	select {
+	case s.requests <- request:
+	case <-s.done:
+		return TransferReport{}, ErrSessionClosed
+	}
+	select {
+	case result := <-request.result:
+		return result.report, result.err
+	case <-s.done:
+		return TransferReport{}, ErrSessionClosed
+	}
+}
+
+func (s *Session) readLoop() {
+	buffer := make([]byte, 1024)
+	for {
+		n, err := s.rw.Read(buffer)
+		for i := 0; i < n; i++ {
+			select {
+			case s.input <- buffer[i]:
+			case <-s.done:
+				return
+			}
+		}
+		if err != nil {
+			s.readErr = err
+			close(s.input)
+			return
+		}
+	}
+}
+
+// neutral state: waiting for the other side to establish the link or for something to send
+func (s *Session) neutral() error {
+	for {
+		select {
+		case b, ok := <-s.input:
+			if !ok {
+				return s.readErr
+			}
+			if b == ENQ {
+				if err := s.receive(); err != nil {
+					return err
+				}
+			}
+		case request := <-s.requests:
+			report, err := s.transmit(request.records)
+			request.result <- sendResult{report: report, err: err}
+			if err != nil && !isLinkError(err) {
+				return err
+			}
+		}
+	}
+}
+
+func isLinkError(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, ErrLinkBusy) || errors.Is(err, ErrTooManyRetransmissions)
+}
+
+// next waits for the next byte from the line
+func (s *Session) next(timeout time.Duration) (byte, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case b, ok := <-s.input:
+		if !ok {
+			return 0, s.readErr
+		}
+		return b, nil
+	case <-timer.C:
+		return 0, ErrTimeout
+	}
+}
+
+func (s *Session) write(data ...byte) error {
+	_, err := s.rw.Write(data)
+	return err
+}
+
+// receive runs after an ENQ was received. Only errors of the underlying connection are returned,
+// a timeout drops the transmission and returns to the neutral state.
+func (s *Session) receive() error {
+	if err := s.write(ACK); err != nil {
+		return err
+	}
+
+	assembler := NewAssembler()
+	previousFrameNumber := -1
+	var message []byte
+
+	for {
+		b, err := s.next(s.config.Timeouts.Receive)
+		if errors.Is(err, ErrTimeout) {
+			return nil // the sender is gone, discard the incomplete transmission
+		}
+		if err != nil {
+			return err
+		}
+
+		switch b {
+		case EOT:
+			if len(message) > 0 && s.handler != nil {
+				s.handler(message)
+			}
+			return nil
+		case STX:
+			raw, err := s.readFrame()
+			if errors.Is(err, ErrTimeout) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			frame, err := ParseFrame(raw)
+			if err == nil && frame.Number == previousFrameNumber {
+				// the sender missed our ACK and repeats the frame: acknowledge, but don't use it twice
+				if err := s.write(ACK); err != nil {
+					return err
+				}
+				continue
+			}
+
+			record, complete, err := assembler.Add(raw)
+			if err != nil {
+				if err := s.write(NAK); err != nil {
+					return err
+				}
+				continue
+			}
+
+			previousFrameNumber = frame.Number
+			if complete {
+				message = append(message, record...)
+				message = append(message, CR)
+			}
+			if err := s.write(ACK); err != nil {
+				return err
+			}
+		default:
+			// anything outside of a frame is line noise
+		}
+	}
+}
+
+// readFrame reads the remainder of a frame after STX up to and including the LF
+func (s *Session) readFrame() ([]byte, error) {
+	raw := []byte{STX}
+	for {
+		b, err := s.next(s.config.Timeouts.Receive)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, b)
+		if b == LF {
+			return raw, nil
+		}
+		if len(raw) > MAX_RECEIVE_FRAME_SIZE {
+			return raw, nil // will fail validation and be NAKed
+		}
+	}
+}
+
+// idle behaves like the neutral state for a limited time, used to wait after contention or a busy receiver
+func (s *Session) idle(duration time.Duration) error {
+	deadline := time.Now().Add(duration)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil
+		}
+		b, err := s.next(remaining)
+		if errors.Is(err, ErrTimeout) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if b == ENQ {
+			if err := s.receive(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Session) transmit(records [][]byte) (TransferReport, error) {
+	var report TransferReport
+	if err := s.establish(); err != nil {
+		return report, err
+	}
+
+	frames := EncodeRecordsWithLimit(records, s.config.MaxTextLength)
+	for i, frame := range frames {
+		result, err := s.transferFrame(frame)
+		report.Frames = append(report.Frames, result)
+		if err != nil {
+			if isLinkError(err) {
+				if eotErr := s.write(EOT); eotErr != nil {
+					return report, eotErr
+				}
+				return report, fmt.Errorf("frame %d of %d: %w", i+1, len(frames), err)
+			}
+			return report, err
+		}
+	}
+
+	return report, s.write(EOT)
+}
+
+// establish sends ENQ until the receiver acknowledges
+func (s *Session) establish() error {
+	busy := 0
+	for {
+		if err := s.write(ENQ); err != nil {
+			return err
+		}
+
+		reply, err := s.awaitReply()
+		if err != nil {
+			if errors.Is(err, ErrTimeout) {
+				if eotErr := s.write(EOT); eotErr != nil {
+					return eotErr
+				}
+			}
+			return err
+		}
+
+		switch reply {
+		case ACK:
+			return nil
+		case NAK, EOT: // the receiver is busy, EOT is not a valid reply and counts as NAK
+			busy++
+			if busy >= MAX_RETRANSMISSIONS {
+				return ErrLinkBusy
+			}
+			if err := s.idle(s.config.Timeouts.Busy); err != nil {
+				return err
+			}
+		case ENQ: // contention
+			// the instrument wins: it repeats its ENQ after its shorter wait, which the computer serves while waiting
+			if err := s.idle(s.config.Timeouts.Contention); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Session) transferFrame(frame []byte) (FrameResult, error) {
+	result := FrameResult{Number: int(frame[1] - '0')}
+	for {
+		result.Attempts++
+		if _, err := s.rw.Write(frame); err != nil {
+			return result, err
+		}
+
+		// every reply counts, LIS1-A 8.3.4 treats characters other than ACK and EOT as NAK
+		reply, err := s.next(s.config.Timeouts.Acknowledge)
+		if err != nil {
+			return result, err
+		}
+
+		switch reply {
+		case ACK, EOT: // EOT is the receiver's interrupt request, the frame was accepted anyways
+			result.Acknowledged = true
+			return result, nil
+		default: // NAK or anything else
+			if result.Attempts >= MAX_RETRANSMISSIONS {
+				return result, ErrTooManyRetransmissions
+			}
+		}
+	}
+}
+
+// awaitReply waits for ACK, NAK, EOT or ENQ, other characters are ignored
+func (s *Session) awaitReply() (byte, error) {
+	deadline := time.Now().Add(s.config.Timeouts.Acknowledge)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return 0, ErrTimeout
+		}
+		b, err := s.next(remaining)
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ACK, NAK, EOT, ENQ:
+			return b, nil
+		}
+	}
+}
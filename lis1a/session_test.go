@@ -0,0 +1,296 @@
+package lis1a
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lib/standardlis2a2"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+)
+
+var sessionTestRecords = [][]byte{
+	[]byte("H|\\^&|||Bio-Rad|IH v5.2||||||||20220315194227"),
+	[]byte("P|1||1010868845||Testus^Test||19400607|M"),
+	[]byte("O|1|1122206642||^^^MO10^^28343^|R"),
+	[]byte("R|1|^^^AntiA^MO10^^|40^^|C||||R||lalina^|20220311114103"),
+	[]byte("L|1|N"),
+}
+
+func expectByte(t *testing.T, conn net.Conn, expected byte) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	b := make([]byte, 1)
+	_, err := conn.Read(b)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, b[0])
+}
+
+func readTestFrame(t *testing.T, conn net.Conn) Frame {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var raw []byte
+	b := make([]byte, 1)
+	for len(raw) == 0 || raw[len(raw)-1] != LF {
+		if _, err := conn.Read(b); err != nil {
+			t.Fatalf("reading frame: %s", err)
+		}
+		raw = append(raw, b[0])
+	}
+	frame, err := ParseFrame(raw)
+	assert.Nil(t, err)
+	return frame
+}
+
+func TestSessionReceivesMessage(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	received := make(chan []byte, 1)
+	session := NewSession(local, func(message []byte) { received <- message }, SessionConfig{})
+	go session.Run()
+
+	remote.Write([]byte{ENQ})
+	expectByte(t, remote, ACK)
+
+	frames := EncodeRecords(sessionTestRecords)
+
+	// a corrupted frame is rejected and repeated by the sender
+	corrupted := append([]byte{}, frames[0]...)
+	corrupted[5] = 'X'
+	remote.Write(corrupted)
+	expectByte(t, remote, NAK)
+
+	for _, frame := range frames {
+		remote.Write(frame)
+		expectByte(t, remote, ACK)
+	}
+	// repeating the last frame (our ACK got lost) must not duplicate the record
+	remote.Write(frames[len(frames)-1])
+	expectByte(t, remote, ACK)
+	remote.Write([]byte{EOT})
+
+	select {
+	case message := <-received:
+		var decoded struct {
+			Header     standardlis2a2.Header     `astm:"H"`
+			Patient    standardlis2a2.Patient    `astm:"P"`
+			Order      standardlis2a2.Order      `astm:"O"`
+			Result     standardlis2a2.Result     `astm:"R"`
+			Terminator standardlis2a2.Terminator `astm:"L"`
+		}
+		err := lis2a2.Unmarshal(message, &decoded, lis2a2.EncodingUTF8, lis2a2.TimezoneEuropeBerlin)
+		assert.Nil(t, err)
+		assert.Equal(t, "Testus", decoded.Patient.LastName)
+		assert.Equal(t, "lalina", decoded.Result.OperatorIDPerformed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message was delivered")
+	}
+}
+
+func TestSessionSendsMessage(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	session := NewSession(local, nil, SessionConfig{})
+	go session.Run()
+
+	result := make(chan error, 1)
+	go func() { result <- session.Send(sessionTestRecords) }()
+
+	expectByte(t, remote, ENQ)
+	remote.Write([]byte{ACK})
+
+	for i, record := range sessionTestRecords {
+		frame := readTestFrame(t, remote)
+		assert.Equal(t, (i+1)%FRAME_NUMBER_MODULO, frame.Number)
+		assert.Equal(t, string(record)+"\r", string(frame.Text))
+		if i == 1 { // reject once, expecting a retransmission
+			remote.Write([]byte{NAK})
+			frame = readTestFrame(t, remote)
+			assert.Equal(t, string(record)+"\r", string(frame.Text))
+		}
+		remote.Write([]byte{ACK})
+	}
+	expectByte(t, remote, EOT)
+
+	assert.Nil(t, <-result)
+}
+
+func TestSessionResendsFrameOnUnexpectedReply(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	session := NewSession(local, nil, SessionConfig{})
+	go session.Run()
+
+	var report TransferReport
+	result := make(chan error, 1)
+	go func() {
+		var err error
+		report, err = session.Transfer(sessionTestRecords[:1])
+		result <- err
+	}()
+
+	expectByte(t, remote, ENQ)
+	remote.Write([]byte{ACK})
+
+	frame := readTestFrame(t, remote)
+	remote.Write([]byte{'x'}) // junk counts as NAK, the frame is sent again without waiting for the timeout
+	resent := readTestFrame(t, remote)
+	assert.Equal(t, frame, resent)
+	remote.Write([]byte{ACK})
+	expectByte(t, remote, EOT)
+
+	assert.Nil(t, <-result)
+	assert.Equal(t, []FrameResult{{Number: 1, Attempts: 2, Acknowledged: true}}, report.Frames)
+}
+
+func TestSessionAbortsAfterSixRetransmissions(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	session := NewSession(local, nil, SessionConfig{})
+	go session.Run()
+
+	var report TransferReport
+	result := make(chan error, 1)
+	go func() {
+		var err error
+		report, err = session.Transfer(sessionTestRecords)
+		result <- err
+	}()
+
+	expectByte(t, remote, ENQ)
+	remote.Write([]byte{ACK})
+
+	readTestFrame(t, remote)
+	remote.Write([]byte{ACK})
+	for i := 0; i < MAX_RETRANSMISSIONS; i++ {
+		readTestFrame(t, remote)
+		remote.Write([]byte{NAK})
+	}
+	expectByte(t, remote, EOT)
+
+	assert.True(t, errors.Is(<-result, ErrTooManyRetransmissions))
+	assert.Equal(t, []FrameResult{
+		{Number: 1, Attempts: 1, Acknowledged: true},
+		{Number: 2, Attempts: MAX_RETRANSMISSIONS, Acknowledged: false},
+	}, report.Frames)
+}
+
+func TestSessionTimeoutWithoutAcknowledge(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	session := NewSession(local, nil, SessionConfig{Timeouts: Timeouts{Acknowledge: 50 * time.Millisecond}})
+	go session.Run()
+
+	result := make(chan error, 1)
+	go func() { result <- session.Send(sessionTestRecords) }()
+
+	expectByte(t, remote, ENQ)
+	expectByte(t, remote, EOT)
+
+	assert.True(t, errors.Is(<-result, ErrTimeout))
+}
+
+func TestSessionCountsEOTAsBusy(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	session := NewSession(local, nil, SessionConfig{Timeouts: Timeouts{Busy: 10 * time.Millisecond}})
+	go session.Run()
+
+	result := make(chan error, 1)
+	go func() { result <- session.Send(sessionTestRecords) }()
+
+	for i := 0; i < MAX_RETRANSMISSIONS; i++ {
+		expectByte(t, remote, ENQ)
+		remote.Write([]byte{EOT})
+	}
+
+	select {
+	case err := <-result:
+		assert.True(t, errors.Is(err, ErrLinkBusy))
+	case <-time.After(2 * time.Second):
+		t.Fatal("EOT was not counted as busy")
+	}
+}
+
+func TestSessionContentionWaitEndsWithConnection(t *testing.T) {
+	local, remote := net.Pipe()
+
+	session := NewSession(local, nil, SessionConfig{Role: RoleInstrument, Timeouts: Timeouts{Contention: time.Minute}})
+	go session.Run()
+
+	result := make(chan error, 1)
+	go func() { result <- session.Send(sessionTestRecords) }()
+
+	expectByte(t, remote, ENQ)
+	remote.Write([]byte{ENQ})
+	remote.Close()
+
+	select {
+	case err := <-result:
+		assert.NotNil(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the session kept waiting after the connection was closed")
+	}
+}
+
+func TestSessionContentionInstrumentWins(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	received := make(chan []byte, 1)
+	session := NewSession(local, func(message []byte) { received <- message },
+		SessionConfig{Role: RoleComputer, Timeouts: Timeouts{Contention: 500 * time.Millisecond}})
+	go session.Run()
+
+	result := make(chan error, 1)
+	go func() { result <- session.Send(sessionTestRecords[:1]) }()
+
+	// both sides bid for the line at the same time
+	expectByte(t, remote, ENQ)
+	remote.Write([]byte{ENQ})
+
+	// the instrument repeats its ENQ and is served first
+	remote.Write([]byte{ENQ})
+	expectByte(t, remote, ACK)
+	for _, frame := range EncodeRecords(sessionTestRecords) {
+		remote.Write(frame)
+		expectByte(t, remote, ACK)
+	}
+	remote.Write([]byte{EOT})
+
+	select {
+	case message := <-received:
+		assert.Contains(t, string(message), "P|1||1010868845")
+	case <-time.After(2 * time.Second):
+		t.Fatal("instrument message was not received")
+	}
+
+	// afterwards the computer retries
+	expectByte(t, remote, ENQ)
+	remote.Write([]byte{ACK})
+	readTestFrame(t, remote)
+	remote.Write([]byte{ACK})
+	expectByte(t, remote, EOT)
+
+	assert.Nil(t, <-result)
+}
+
+func TestSessionEndsWithConnection(t *testing.T) {
+	local, remote := net.Pipe()
+
+	session := NewSession(local, nil, SessionConfig{})
+	result := make(chan error, 1)
+	go func() { result <- session.Run() }()
+
+	remote.Close()
+	assert.NotNil(t, <-result)
+	assert.Equal(t, ErrSessionClosed, session.Send(sessionTestRecords))
+}
@@ -0,0 +1,112 @@
+package lis2a2
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	marshalerType       = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// isComponentStruct tells if the type is a struct whose members are mapped to the components of a field.
+// time.Time and custom field types are single values.
+func isComponentStruct(fieldType reflect.Type) bool {
+	if fieldType.Kind() != reflect.Struct || fieldType == timeType {
+		return false
+	}
+	for _, customType := range []reflect.Type{unmarshalerType, marshalerType, textUnmarshalerType, textMarshalerType} {
+		if reflect.PtrTo(fieldType).Implements(customType) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasComponentStructs tells if the field, the elements of a slice or the target of a pointer are component structs
+func hasComponentStructs(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Ptr || fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	return isComponentStruct(fieldType)
+}
+
+// readComponentAnnotation reads the annotation of a member of a component struct, e.g. "2" or "3,longdate".
+// The returned component counts from 0.
+func readComponentAnnotation(astmTag string) (int, []string, error) {
+	astmTagsList := strings.Split(astmTag, ",")
+	for i := range astmTagsList {
+		astmTagsList[i] = strings.Trim(astmTagsList[i], " ")
+	}
+	component, err := strconv.Atoi(astmTagsList[0])
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %s", ErrInvalidAnnotation, err)
+	}
+	if component < 1 {
+		return 0, nil, fmt.Errorf("%w: component %d, components start with 1", ErrInvalidAnnotation, component)
+	}
+	return component - 1, astmTagsList, nil
+}
+
+// unmarshalComponents maps the components of one repeat of the field to the annotated members of the
+// component struct. In strict mode required members must not be empty. On error the component of the failing member is returned.
+func unmarshalComponents(target reflect.Value, fieldText string, repeat int, delimiters Delimiters, timezone *time.Location, strict bool) (int, error) {
+	for i := 0; i < target.NumField(); i++ {
+		astmTag := target.Type().Field(i).Tag.Get("astm")
+		if astmTag == "" {
+			continue
+		}
+		if !target.Field(i).CanSet() {
+			return 0, fmt.Errorf("%w: member %s is not exported", ErrUnsupportedType, target.Type().Field(i).Name)
+		}
+
+		component, astmTagsList, err := readComponentAnnotation(astmTag)
+		if err != nil {
+			return 0, err
+		}
+		isRequired := sliceContainsString(astmTagsList, ANNOTATION_REQUIRED)
+
+		value, err := extractAstmFieldByRepeatAndComponent(fieldText, repeat, component, delimiters, isRequired)
+		if err != nil {
+			return component, err
+		}
+		if strict && isRequired && value == "" {
+			return component, fmt.Errorf("%w: the component is empty", ErrMissingValue)
+		}
+		if err := unmarshalValue(target.Field(i), value, astmTagsList, timezone); err != nil {
+			return component, err
+		}
+	}
+	return 0, nil
+}
+
+// marshalComponents adds the annotated members of the component struct as components of one repeat of the field
+func marshalComponents(fieldList OutputRecords, value reflect.Value, fieldIdx, repeatIdx int, location *time.Location, delimiters Delimiters) (OutputRecords, error) {
+	for i := 0; i < value.NumField(); i++ {
+		astmTag := value.Type().Field(i).Tag.Get("astm")
+		if astmTag == "" {
+			continue
+		}
+		if !value.Field(i).CanInterface() {
+			return nil, fmt.Errorf("%w: member %s is not exported", ErrUnsupportedType, value.Type().Field(i).Name)
+		}
+
+		component, astmTagsList, err := readComponentAnnotation(astmTag)
+		if err != nil {
+			return nil, err
+		}
+		output, err := marshalValue(value.Field(i), astmTagsList, location)
+		if err != nil {
+			return nil, err
+		}
+		fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx, component, escapeValue(output, delimiters))
+	}
+	return fieldList, nil
+}
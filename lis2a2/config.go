@@ -0,0 +1,72 @@
+package lis2a2
+
+import "time"
+
+// Delimiters used to separate fields, repeats and components, and to start escape sequences
+type Delimiters struct {
+	Field     string
+	Repeat    string
+	Component string
+	Escape    string
+}
+
+var DefaultDelimiters = Delimiters{
+	Field:     "|",
+	Repeat:    "\\",
+	Component: "^",
+	Escape:    "&",
+}
+
+// Config holds the settings for decoding and encoding messages. Zero values are replaced by the defaults.
+type Config struct {
+	Encoding  Encoding       // default EncodingUTF8
+	Timezone  Timezone       // used if no Location is given, default TimezoneUTC
+	Location  *time.Location // takes precedence over Timezone
+	Notation  Notation       // output only
+	LineBreak LineBreak      // output only, terminating every record
+	// Delimiters to start with. When decoding, they are replaced by those declared in the header (H record),
+	// when encoding they are written to fields annotated with "delimiter" that have no value.
+	Delimiters Delimiters
+	// Lenient decoding does not stop at the first error: invalid values are left empty, records of a type unknown
+	// to the struct are skipped and missing records are tolerated. All problems are returned as ParseErrors
+	// together with the partially filled struct.
+	Lenient bool
+	// Strict decoding rejects non-empty values that are not mapped by any annotation (ErrUnmappedValue), required
+	// fields beyond the end of the record and records of a type unknown to the struct (ErrUnexpectedRecord)
+	Strict bool
+}
+
+var DefaultConfig = Config{
+	Encoding:   EncodingUTF8,
+	Timezone:   TimezoneUTC,
+	Notation:   StandardNotation,
+	LineBreak:  CR,
+	Delimiters: DefaultDelimiters,
+}
+
+func (c Config) location() (*time.Location, error) {
+	if c.Location != nil {
+		return c.Location, nil
+	}
+	if c.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(string(c.Timezone))
+}
+
+func (c Config) delimiters() Delimiters {
+	delimiters := c.Delimiters
+	if delimiters.Field == "" {
+		delimiters.Field = DefaultDelimiters.Field
+	}
+	if delimiters.Repeat == "" {
+		delimiters.Repeat = DefaultDelimiters.Repeat
+	}
+	if delimiters.Component == "" {
+		delimiters.Component = DefaultDelimiters.Component
+	}
+	if delimiters.Escape == "" {
+		delimiters.Escape = DefaultDelimiters.Escape
+	}
+	return delimiters
+}
@@ -6,6 +6,11 @@ const (
 	ANNOTATION_OPTIONAL  = "optional"  // record-annotation: by default all records are mandatory
 	ANNOTATION_SEQUENCE  = "sequence"  // indicating that a sequence number should be generated (output only)
 	ANNOTATION_LONGDATE  = "longdate"
+	ANNOTATION_RAW       = "raw"       // field-annotation: keeps the original record for a lossless marshal
+	ANNOTATION_TRUE      = "true"      // field-annotation with value: the literal of true for bool fields, e.g. "true=1" (default Y)
+	ANNOTATION_FALSE     = "false"     // field-annotation with value: the literal of false for bool fields, e.g. "false=0" (default N)
+	ANNOTATION_PRECISION = "precision" // field-annotation with value: decimal places of floats on output, e.g. "precision=2" (default 3)
+	ANNOTATION_DECIMAL   = "decimal"   // field-annotation with value: "decimal=comma" for floats written with a decimal comma
 )
 
 type Encoding int
@@ -29,9 +34,9 @@ const TimezoneEuropeLondon Timezone = "Europe/London"
 
 type LineBreak int
 
-const CR LineBreak = 0x13
-const LF LineBreak = 0x10
-const CRLF LineBreak = 0x1310
+const CR LineBreak = 0x0D
+const LF LineBreak = 0x0A
+const CRLF LineBreak = 0x0D0A
 
 /* Notation defines how the output format is build
 ShortNotation will skip all delimiters to the right of the last value
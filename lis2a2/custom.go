@@ -0,0 +1,133 @@
+package lis2a2
+
+import (
+	"encoding"
+	"reflect"
+	"time"
+)
+
+// Unmarshaler is implemented by field types that decode themselves from the value of a field or component.
+// The value is already unescaped. UnmarshalASTM is not called for empty values, the field keeps its zero value.
+type Unmarshaler interface {
+	UnmarshalASTM(value string) error
+}
+
+// Marshaler is implemented by field types that encode themselves into the value of a field or component.
+// The returned value is escaped like a string.
+type Marshaler interface {
+	MarshalASTM() (string, error)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// unmarshalerOf returns the Unmarshaler of the (addressable) field, nil if it does not implement it
+func unmarshalerOf(field reflect.Value) Unmarshaler {
+	if !field.CanAddr() {
+		return nil
+	}
+	if unmarshaler, ok := field.Addr().Interface().(Unmarshaler); ok {
+		return unmarshaler
+	}
+	return nil
+}
+
+// textUnmarshalerOf returns the encoding.TextUnmarshaler of the (addressable) field, nil if it does not implement
+// it. time.Time is excluded, the ASTM date formats are handled separately.
+func textUnmarshalerOf(field reflect.Value) encoding.TextUnmarshaler {
+	if !field.CanAddr() || field.Type() == timeType {
+		return nil
+	}
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler
+	}
+	return nil
+}
+
+// unmarshalCustomValue decodes the value with UnmarshalASTM, or UnmarshalText as a fallback
+func unmarshalCustomValue(field reflect.Value, value string) error {
+	if value == "" {
+		return nil
+	}
+	if unmarshaler := unmarshalerOf(field); unmarshaler != nil {
+		return unmarshaler.UnmarshalASTM(value)
+	}
+	return textUnmarshalerOf(field).UnmarshalText([]byte(value))
+}
+
+// addressable returns a pointer to the value, a copy if the value itself is not addressable
+// (the methods of a Marshaler may have a pointer receiver)
+func addressable(field reflect.Value) reflect.Value {
+	if field.CanAddr() {
+		return field.Addr()
+	}
+	pointer := reflect.New(field.Type())
+	pointer.Elem().Set(field)
+	return pointer
+}
+
+// marshalerOf returns the Marshaler of the field, nil if it does not implement it
+func marshalerOf(field reflect.Value) Marshaler {
+	if !field.CanInterface() {
+		return nil
+	}
+	if marshaler, ok := addressable(field).Interface().(Marshaler); ok {
+		return marshaler
+	}
+	return nil
+}
+
+// textMarshalerOf returns the encoding.TextMarshaler of the field, nil if it does not implement it.
+// time.Time is excluded, the ASTM date formats are handled separately.
+func textMarshalerOf(field reflect.Value) encoding.TextMarshaler {
+	if !field.CanInterface() || field.Type() == timeType {
+		return nil
+	}
+	if marshaler, ok := addressable(field).Interface().(encoding.TextMarshaler); ok {
+		return marshaler
+	}
+	return nil
+}
+
+// marshalCustomValue encodes the value with MarshalASTM, or MarshalText as a fallback
+func marshalCustomValue(field reflect.Value) (string, error) {
+	if marshaler := marshalerOf(field); marshaler != nil {
+		return marshaler.MarshalASTM()
+	}
+	text, err := textMarshalerOf(field).MarshalText()
+	return string(text), err
+}
+
+// RecordUnmarshaler is implemented by record structs that decode themselves, e.g. manufacturer records
+// changing their layout. The record is split by the delimiters of the message, the annotations of the
+// struct's fields are not used. The record is still placed by the annotation of the struct in the message.
+type RecordUnmarshaler interface {
+	UnmarshalASTMRecord(record *Record) error
+}
+
+// RecordMarshaler is implemented by record structs that encode themselves. The record type (field 1)
+// is set from the annotation of the struct in the message.
+type RecordMarshaler interface {
+	MarshalASTMRecord() (*Record, error)
+}
+
+// recordUnmarshalerOf returns the RecordUnmarshaler of the (addressable) record, nil if it does not implement it
+func recordUnmarshalerOf(record reflect.Value) RecordUnmarshaler {
+	if !record.CanAddr() {
+		return nil
+	}
+	if unmarshaler, ok := record.Addr().Interface().(RecordUnmarshaler); ok {
+		return unmarshaler
+	}
+	return nil
+}
+
+// recordMarshalerOf returns the RecordMarshaler of the record, nil if it does not implement it
+func recordMarshalerOf(record reflect.Value) RecordMarshaler {
+	if !record.CanInterface() {
+		return nil
+	}
+	if marshaler, ok := addressable(record).Interface().(RecordMarshaler); ok {
+		return marshaler
+	}
+	return nil
+}
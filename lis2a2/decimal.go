@@ -0,0 +1,58 @@
+package lis2a2
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	decimalPattern = regexp.MustCompile(`^[+-]?([0-9]+([.,][0-9]*)?|[.,][0-9]+)$`)
+	decimalType    = reflect.TypeOf(Decimal(""))
+)
+
+// Decimal is a number kept exactly as it was received, like json.Number. Values such as "0.0001" or the
+// "7,41" (decimal comma) of some instruments are not changed by float formatting when the message is written again.
+type Decimal string
+
+// UnmarshalASTM accepts numbers with a decimal point or a decimal comma
+func (d *Decimal) UnmarshalASTM(value string) error {
+	if !decimalPattern.MatchString(value) {
+		return fmt.Errorf("'%s' is not a decimal number", value)
+	}
+	*d = Decimal(value)
+	return nil
+}
+
+// MarshalASTM writes the number as it is
+func (d Decimal) MarshalASTM() (string, error) {
+	return string(d), nil
+}
+
+// String returns the number as it was received
+func (d Decimal) String() string {
+	return string(d)
+}
+
+// Float64 returns the number as float64, which may lose precision
+func (d Decimal) Float64() (float64, error) {
+	return strconv.ParseFloat(normalizeDecimal(string(d)), 64)
+}
+
+// Int64 returns the number as int64, it fails for numbers with decimals
+func (d Decimal) Int64() (int64, error) {
+	return strconv.ParseInt(string(d), 10, 64)
+}
+
+// Rat returns the exact value of the number, false if it is not a valid number (e.g. empty)
+func (d Decimal) Rat() (*big.Rat, bool) {
+	return new(big.Rat).SetString(normalizeDecimal(string(d)))
+}
+
+// normalizeDecimal replaces a decimal comma with a decimal point
+func normalizeDecimal(value string) string {
+	return strings.Replace(value, ",", ".", 1)
+}
@@ -0,0 +1,37 @@
+package lis2a2
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalUnmarshal(t *testing.T) {
+	for _, value := range []string{"7,41", "0.0001", "-3", "+12.50", ".5", "100."} {
+		var d Decimal
+		assert.Nil(t, d.UnmarshalASTM(value), value)
+		assert.Equal(t, value, d.String())
+	}
+	for _, value := range []string{"abc", "1/3", "1e5", "1.2.3", "1,2,3", "<0.1", "-"} {
+		var d Decimal
+		assert.NotNil(t, d.UnmarshalASTM(value), value)
+	}
+}
+
+func TestDecimalConversions(t *testing.T) {
+	f, err := Decimal("7,41").Float64()
+	assert.Nil(t, err)
+	assert.Equal(t, 7.41, f)
+
+	i, err := Decimal("42").Int64()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), i)
+
+	_, err = Decimal("4.2").Int64()
+	assert.NotNil(t, err)
+
+	r, ok := Decimal("0.0001").Rat()
+	assert.True(t, ok)
+	assert.Equal(t, big.NewRat(1, 10000), r)
+}
@@ -0,0 +1,143 @@
+package lis2a2
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Decoder reads messages from a stream one at a time. Only the records of the current message
+// are kept in memory, so arbitrarily long transmissions (e.g. batch exports) can be processed.
+type Decoder struct {
+	reader *bufio.Reader
+	config Config
+	err    error
+	next   string // H record of the following message, read while looking for the end of the current one
+
+	nextLineNumber int
+	lineCount      int  // lines read so far, for the position in errors
+	previous       byte // last byte read, CR LF ends one line only
+}
+
+func NewDecoder(reader io.Reader, config Config) *Decoder {
+	decoder := &Decoder{config: config}
+
+	charset, err := charmapOf(config.Encoding)
+	if err != nil {
+		decoder.err = err
+	} else if charset != nil {
+		reader = charset.NewDecoder().Reader(reader)
+	}
+	decoder.reader = bufio.NewReader(reader)
+
+	return decoder
+}
+
+// Decode reads the next message from the stream and stores it in targetStruct, like Unmarshal.
+// A message ends with an L record, or when the next H record or the end of the stream is reached.
+// Records may be terminated by CR, LF or any mix of both. io.EOF is returned when there are no more messages.
+func (d *Decoder) Decode(targetStruct interface{}) error {
+	lines, lineNumbers, err := d.readMessage()
+	if err != nil {
+		return err
+	}
+	return unmarshalLines(lines, lineNumbers, targetStruct, d.config)
+}
+
+// DecodeMessage reads the next message into the generic representation. io.EOF is returned when there are no more messages.
+func (d *Decoder) DecodeMessage() (*Message, error) {
+	lines, _, err := d.readMessage()
+	if err != nil {
+		return nil, err
+	}
+	return parseMessage(lines, d.config), nil
+}
+
+// readMessage reads the records of the next message and their line numbers
+func (d *Decoder) readMessage() ([]string, []int, error) {
+	lines := []string{}
+	lineNumbers := []int{}
+	if d.next != "" {
+		lines = append(lines, d.next)
+		lineNumbers = append(lineNumbers, d.nextLineNumber)
+		d.next = ""
+	}
+
+	for {
+		if d.err != nil {
+			if len(lines) > 0 {
+				break
+			}
+			return nil, nil, d.err
+		}
+
+		line, lineNumber, err := d.readLine()
+		if err != nil {
+			d.err = err
+		}
+		if strings.Trim(line, " ") == "" {
+			continue
+		}
+
+		if line[0] == 'H' && len(lines) > 0 {
+			d.next = line
+			d.nextLineNumber = lineNumber
+			break
+		}
+		lines = append(lines, line)
+		lineNumbers = append(lineNumbers, lineNumber)
+		if line[0] == 'L' {
+			break
+		}
+	}
+
+	return lines, lineNumbers, nil
+}
+
+// readLine reads up to the next CR or LF (excluding it) and returns the line with its number
+func (d *Decoder) readLine() (string, int, error) {
+	var line strings.Builder
+	for {
+		b, err := d.reader.ReadByte()
+		if err != nil {
+			return line.String(), d.lineCount + 1, err
+		}
+		previous := d.previous
+		d.previous = b
+		if b == 0x0A && previous == 0x0D && line.Len() == 0 {
+			continue
+		}
+		if b == 0x0D || b == 0x0A {
+			d.lineCount++
+			return line.String(), d.lineCount, nil
+		}
+		line.WriteByte(b)
+	}
+}
+
+// charmapOf returns the charset for the encoding, nil for encodings that need no conversion
+func charmapOf(enc Encoding) (*charmap.Charmap, error) {
+	switch enc {
+	case 0, EncodingUTF8, EncodingASCII:
+		return nil, nil
+	case EncodingDOS866:
+		return charmap.CodePage866, nil
+	case EncodingDOS855:
+		return charmap.CodePage855, nil
+	case EncodingDOS852:
+		return charmap.CodePage852, nil
+	case EncodingWindows1250:
+		return charmap.Windows1250, nil
+	case EncodingWindows1251:
+		return charmap.Windows1251, nil
+	case EncodingWindows1252:
+		return charmap.Windows1252, nil
+	case EncodingISO8859_1:
+		return charmap.ISO8859_1, nil
+	default:
+		return nil, fmt.Errorf("invalid Codepage Id='%d'", enc)
+	}
+}
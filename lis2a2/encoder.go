@@ -0,0 +1,69 @@
+package lis2a2
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Encoder writes messages to a stream, every record terminated by the configured line break
+type Encoder struct {
+	writer io.Writer
+	config Config
+}
+
+func NewEncoder(writer io.Writer, config Config) *Encoder {
+	if config.Notation == 0 {
+		config.Notation = StandardNotation
+	}
+	if config.LineBreak == 0 {
+		config.LineBreak = CR
+	}
+	return &Encoder{writer: writer, config: config}
+}
+
+// Encode marshals the message (see MarshalWithConfig) and writes all of its records with a single Write
+func (e *Encoder) Encode(message interface{}) error {
+	records, err := MarshalWithConfig(message, e.config)
+	if err != nil {
+		return err
+	}
+	return e.write(records)
+}
+
+// EncodeMessage writes a message in the generic representation (see MarshalMessage)
+func (e *Encoder) EncodeMessage(message *Message) error {
+	records, err := MarshalMessage(message, e.config)
+	if err != nil {
+		return err
+	}
+	return e.write(records)
+}
+
+func (e *Encoder) write(records [][]byte) error {
+	lineBreak, err := lineBreakBytes(e.config.LineBreak)
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	for _, record := range records {
+		buffer.Write(record)
+		buffer.Write(lineBreak)
+	}
+	_, err = e.writer.Write(buffer.Bytes())
+	return err
+}
+
+func lineBreakBytes(lineBreak LineBreak) ([]byte, error) {
+	switch lineBreak {
+	case CR:
+		return []byte{0x0D}, nil
+	case LF:
+		return []byte{0x0A}, nil
+	case CRLF:
+		return []byte{0x0D, 0x0A}, nil
+	default:
+		return nil, fmt.Errorf("invalid LineBreak='%d'", lineBreak)
+	}
+}
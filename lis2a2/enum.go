@@ -0,0 +1,32 @@
+package lis2a2
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Enum is implemented by named string types with a fixed set of codes, e.g. the code tables of LIS2-A2.
+// Unmarshal and Marshal fail with ErrNotAllowedValue for any other non-empty value, in lenient mode the
+// error is collected and the field stays empty.
+type Enum interface {
+	AllowedValues() []string
+}
+
+// checkAllowedValue verifies the value of an Enum field
+func checkAllowedValue(field reflect.Value, value string) error {
+	if value == "" || !field.CanInterface() {
+		return nil
+	}
+	enum, ok := addressable(field).Interface().(Enum)
+	if !ok {
+		return nil
+	}
+	allowedValues := enum.AllowedValues()
+	for _, allowed := range allowedValues {
+		if value == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: '%s' is not one of %s", ErrNotAllowedValue, value, strings.Join(allowedValues, ", "))
+}
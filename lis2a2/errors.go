@@ -0,0 +1,117 @@
+package lis2a2
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The cause of a ParseError, to be tested with errors.Is
+var (
+	ErrNoInput           = errors.New("input contains no data")
+	ErrUnexpectedRecord  = errors.New("unexpected record")
+	ErrPrematureEnd      = errors.New("premature end of input")
+	ErrSkippedInput      = errors.New("lines of input were skipped")
+	ErrMissingValue      = errors.New("required value is missing")
+	ErrUnmappedValue     = errors.New("value is not mapped")
+	ErrInvalidValue      = errors.New("invalid value")
+	ErrNotAllowedValue   = errors.New("value is not allowed")
+	ErrInvalidAnnotation = errors.New("invalid annotation")
+	ErrUnsupportedType   = errors.New("unsupported type")
+	ErrMaxDepth          = errors.New("maximum recursion depth reached")
+)
+
+// Errors of the path based access to a Message
+var (
+	ErrInvalidPath  = errors.New("invalid path")
+	ErrPathNotFound = errors.New("no such record")
+)
+
+// ParseError describes where decoding a message failed. Positions start with 1, a position
+// of 0 means it does not apply (e.g. Field for a record that was not expected at all).
+type ParseError struct {
+	Line       int    // line of the input (for the Decoder counted from the start of the stream)
+	RecordType string // e.g. "P"
+	Field      int
+	Repeat     int
+	Component  int
+	FieldName  string // name of the struct field the value was meant for
+	Value      string // the raw field, or the whole record if the error is not related to a field
+	Err        error
+}
+
+func (e *ParseError) Error() string {
+	position := fmt.Sprintf("line %d", e.Line)
+	if e.RecordType != "" {
+		position += fmt.Sprintf(" record '%s'", e.RecordType)
+	}
+	if e.Field > 0 {
+		position += fmt.Sprintf(" field %d.%d.%d", e.Field, e.Repeat, e.Component)
+	}
+	if e.FieldName != "" {
+		position += fmt.Sprintf(" (%s)", e.FieldName)
+	}
+	if e.Value != "" {
+		position += fmt.Sprintf(" '%s'", e.Value)
+	}
+	return fmt.Sprintf("%s: %s", position, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// recordError attaches the position of the record to an error, errors of a field keep their position
+func recordError(err error, lineIndex int, line string) *ParseError {
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		parseError = &ParseError{Value: line, Err: err}
+	}
+	parseError.Line = lineIndex + 1
+	if len(line) > 0 {
+		parseError.RecordType = line[0:1]
+	}
+	return parseError
+}
+
+// ParseErrors lists every problem found in a message decoded in lenient mode (see Config.Lenient),
+// ordered by line
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, parseError := range e {
+		messages[i] = parseError.Error()
+	}
+	return fmt.Sprintf("%d error(s) in message: %s", len(e), strings.Join(messages, "; "))
+}
+
+// Unwrap allows errors.Is and errors.As to test the single errors
+func (e ParseErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, parseError := range e {
+		errs[i] = parseError
+	}
+	return errs
+}
+
+// Is reports whether any of the single errors matches the target. Go before 1.20 does not follow
+// Unwrap() []error, so errors.Is relies on this method there.
+func (e ParseErrors) Is(target error) bool {
+	for _, parseError := range e {
+		if errors.Is(parseError, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the single errors that matches the target, see Is
+func (e ParseErrors) As(target interface{}) bool {
+	for _, parseError := range e {
+		if errors.As(parseError, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package lis2a2
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Is and As are called directly, errors.Is and errors.As do not use Unwrap() []error before Go 1.20
+func TestParseErrorsIsAndAs(t *testing.T) {
+	parseErrors := ParseErrors{
+		{Line: 2, RecordType: "P", Err: ErrMissingValue},
+		{Line: 3, RecordType: "R", Field: 4, Err: ErrInvalidValue},
+	}
+
+	assert.True(t, parseErrors.Is(ErrInvalidValue))
+	assert.True(t, parseErrors.Is(ErrMissingValue))
+	assert.False(t, parseErrors.Is(ErrUnmappedValue))
+
+	var parseError *ParseError
+	assert.True(t, parseErrors.As(&parseError))
+	assert.Equal(t, 2, parseError.Line)
+
+	var wrapped error = parseErrors
+	assert.True(t, errors.Is(wrapped, ErrInvalidValue))
+	assert.False(t, ParseErrors{}.Is(ErrInvalidValue))
+	assert.False(t, ParseErrors{}.As(&parseError))
+}
@@ -0,0 +1,100 @@
+package lis2a2
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// Escape sequences (see Section 5.6 https://samson-rus.com/wp-content/files/LIS2-A2.pdf), written
+// between two escape delimiters, e.g. "&F&"
+const (
+	ESCAPE_FIELD     = "F" // field delimiter
+	ESCAPE_COMPONENT = "S" // component delimiter
+	ESCAPE_REPEAT    = "R" // repeat delimiter
+	ESCAPE_ESCAPE    = "E" // escape delimiter
+	ESCAPE_HEX       = "X" // followed by pairs of hex digits
+	ESCAPE_HIGHLIGHT = "H" // start highlighting text
+	ESCAPE_NORMAL    = "N" // end highlighting text
+)
+
+// escapeValue replaces the delimiters in a value with their escape sequences
+func escapeValue(value string, delimiters Delimiters) string {
+	if delimiters.Escape == "" {
+		return value
+	}
+
+	replacements := []struct{ delimiter, sequence string }{
+		{delimiters.Escape, ESCAPE_ESCAPE}, // first, as it is part of the other sequences
+		{delimiters.Field, ESCAPE_FIELD},
+		{delimiters.Component, ESCAPE_COMPONENT},
+		{delimiters.Repeat, ESCAPE_REPEAT},
+	}
+
+	var output strings.Builder
+	for i := 0; i < len(value); {
+		replaced := false
+		for _, r := range replacements {
+			if r.delimiter != "" && strings.HasPrefix(value[i:], r.delimiter) {
+				output.WriteString(delimiters.Escape + r.sequence + delimiters.Escape)
+				i += len(r.delimiter)
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			output.WriteByte(value[i])
+			i++
+		}
+	}
+	return output.String()
+}
+
+// unescapeValue replaces the escape sequences in a value. Highlighting is dropped, unknown
+// sequences (e.g. local "&Z..&" extensions) and malformed ones are kept as they are.
+func unescapeValue(value string, delimiters Delimiters) string {
+	if delimiters.Escape == "" || !strings.Contains(value, delimiters.Escape) {
+		return value
+	}
+
+	var output strings.Builder
+	for {
+		start := strings.Index(value, delimiters.Escape)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(value[start+len(delimiters.Escape):], delimiters.Escape)
+		if end < 0 {
+			break
+		}
+		end += start + len(delimiters.Escape)
+
+		output.WriteString(value[:start])
+		sequence := value[start+len(delimiters.Escape) : end]
+
+		switch {
+		case sequence == ESCAPE_FIELD:
+			output.WriteString(delimiters.Field)
+		case sequence == ESCAPE_COMPONENT:
+			output.WriteString(delimiters.Component)
+		case sequence == ESCAPE_REPEAT:
+			output.WriteString(delimiters.Repeat)
+		case sequence == ESCAPE_ESCAPE:
+			output.WriteString(delimiters.Escape)
+		case sequence == ESCAPE_HIGHLIGHT, sequence == ESCAPE_NORMAL:
+			// formatting is not represented in the value
+		case strings.HasPrefix(sequence, ESCAPE_HEX) && len(sequence) > 1:
+			if data, err := hex.DecodeString(sequence[1:]); err == nil {
+				output.Write(data)
+			} else {
+				output.WriteString(value[start : end+len(delimiters.Escape)])
+			}
+		default:
+			output.WriteString(value[start : end+len(delimiters.Escape)])
+		}
+
+		value = value[end+len(delimiters.Escape):]
+	}
+	output.WriteString(value)
+
+	return output.String()
+}
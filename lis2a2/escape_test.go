@@ -0,0 +1,38 @@
+package lis2a2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeValue(t *testing.T) {
+	assert.Equal(t, "plain", escapeValue("plain", DefaultDelimiters))
+	assert.Equal(t, "A&F&B&S&C&R&D&E&E", escapeValue("A|B^C\\D&E", DefaultDelimiters))
+
+	custom := Delimiters{Field: "!", Repeat: "~", Component: "#", Escape: "$"}
+	assert.Equal(t, "1$F$2$S$3$R$4$E$5|^\\&", escapeValue("1!2#3~4$5|^\\&", custom))
+}
+
+func TestUnescapeValue(t *testing.T) {
+	assert.Equal(t, "plain", unescapeValue("plain", DefaultDelimiters))
+	assert.Equal(t, "A|B^C\\D&E", unescapeValue("A&F&B&S&C&R&D&E&E", DefaultDelimiters))
+
+	// hex data
+	assert.Equal(t, "line1\r\nline2", unescapeValue("line1&X0D0A&line2", DefaultDelimiters))
+
+	// highlighting is dropped
+	assert.Equal(t, "very important", unescapeValue("very &H&important&N&", DefaultDelimiters))
+
+	// unknown, malformed and unterminated sequences are kept
+	assert.Equal(t, "&Zlocal& &XZZ& Tom & Jerry", unescapeValue("&Zlocal& &XZZ& Tom & Jerry", DefaultDelimiters))
+
+	custom := Delimiters{Field: "!", Repeat: "~", Component: "#", Escape: "$"}
+	assert.Equal(t, "1!2#3~4$5&F&", unescapeValue("1$F$2$S$3$R$4$E$5&F&", custom))
+}
+
+func TestEscapeRoundTrip(t *testing.T) {
+	for _, value := range []string{"", "|", "&&", "&E&", "a^b\\c|d&e", "&X41&"} {
+		assert.Equal(t, value, unescapeValue(escapeValue(value, DefaultDelimiters), DefaultDelimiters))
+	}
+}
@@ -0,0 +1,176 @@
+package lis2a2
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Message is the schema-less representation of one message (H to L record). It can be read and
+// written without defining annotated structs, e.g. for tools and logging.
+type Message struct {
+	Delimiters Delimiters // as declared in the header
+	Records    []*Record
+}
+
+// Record holds the fields of one record. Fields[0] is the record type, so the numbering of the
+// accessors is the same as in the annotations: Field(3) is what `astm:"3"` maps.
+type Record struct {
+	Fields []Field
+}
+
+// Field holds the repeats of a field, a field without repeat delimiter has one repeat
+type Field struct {
+	Repeats []Repeat
+}
+
+// Repeat holds the components of one repeat, values are unescaped
+type Repeat struct {
+	Components []string
+}
+
+// ParseMessages reads all messages of a transmission into the generic representation
+func ParseMessages(messageData []byte, config Config) ([]*Message, error) {
+	decoder := NewDecoder(bytes.NewReader(messageData), config)
+
+	messages := []*Message{}
+	for {
+		message, err := decoder.DecodeMessage()
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, message)
+	}
+}
+
+func parseMessage(lines []string, config Config) *Message {
+	message := &Message{Delimiters: config.delimiters()}
+	for _, line := range lines {
+		if line[0] == 'H' {
+			message.Delimiters = headerDelimiters(line, message.Delimiters)
+		}
+		message.Records = append(message.Records, parseRecord(line, message.Delimiters))
+	}
+	return message
+}
+
+// headerDelimiters returns the delimiters declared in a header record, those not declared stay as they are
+func headerDelimiters(line string, delimiters Delimiters) Delimiters {
+	if len(line) < 2 {
+		return delimiters
+	}
+	delimiters.Field = line[1:2]
+	declared := strings.Split(line, delimiters.Field)[1]
+	if len(declared) >= 1 {
+		delimiters.Repeat = declared[0:1]
+	}
+	if len(declared) >= 2 {
+		delimiters.Component = declared[1:2]
+	}
+	if len(declared) >= 3 {
+		delimiters.Escape = declared[2:3]
+	}
+	return delimiters
+}
+
+func parseRecord(line string, delimiters Delimiters) *Record {
+	record := &Record{}
+	for i, fieldValue := range strings.Split(line, delimiters.Field) {
+		if line[0] == 'H' && i == 1 { // the delimiters themselves
+			record.Fields = append(record.Fields, Field{Repeats: []Repeat{{Components: []string{fieldValue}}}})
+			continue
+		}
+		field := Field{}
+		for _, repeatValue := range strings.Split(fieldValue, delimiters.Repeat) {
+			repeat := Repeat{}
+			for _, component := range strings.Split(repeatValue, delimiters.Component) {
+				repeat.Components = append(repeat.Components, unescapeValue(component, delimiters))
+			}
+			field.Repeats = append(field.Repeats, repeat)
+		}
+		record.Fields = append(record.Fields, field)
+	}
+	return record
+}
+
+// Type returns the record type, e.g. "P"
+func (r *Record) Type() string {
+	return r.Field(1).Value()
+}
+
+// Field returns the field by its number (starting with 1 for the record type), nil if the record is shorter
+func (r *Record) Field(number int) *Field {
+	if r == nil || number < 1 || number > len(r.Fields) {
+		return nil
+	}
+	return &r.Fields[number-1]
+}
+
+// Repeat returns the repeat by its number (starting with 1), nil if there are less repeats
+func (f *Field) Repeat(number int) *Repeat {
+	if f == nil || number < 1 || number > len(f.Repeats) {
+		return nil
+	}
+	return &f.Repeats[number-1]
+}
+
+// Value returns the first component of the first repeat
+func (f *Field) Value() string {
+	return f.Repeat(1).Component(1)
+}
+
+// Component returns the value of the component by its number (starting with 1), "" if there are less components
+func (r *Repeat) Component(number int) string {
+	if r == nil || number < 1 || number > len(r.Components) {
+		return ""
+	}
+	return r.Components[number-1]
+}
+
+// String renders the record with the delimiters, escaping the values
+func (r *Record) String(delimiters Delimiters) string {
+	fields := make([]string, len(r.Fields))
+	for i, field := range r.Fields {
+		if r.Type() == "H" && i == 1 {
+			fields[i] = delimiters.Repeat + delimiters.Component + delimiters.Escape
+			continue
+		}
+		repeats := make([]string, len(field.Repeats))
+		for j, repeat := range field.Repeats {
+			components := make([]string, len(repeat.Components))
+			for k, component := range repeat.Components {
+				components[k] = escapeValue(component, delimiters)
+			}
+			repeats[j] = strings.Join(components, delimiters.Component)
+		}
+		fields[i] = strings.Join(repeats, delimiters.Repeat)
+	}
+	return strings.Join(fields, delimiters.Field)
+}
+
+// MarshalMessage renders the records of the message like Marshal does for structs, using the
+// delimiters of the message and the encoding of the config
+func MarshalMessage(message *Message, config Config) ([][]byte, error) {
+	charset, err := charmapOf(config.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	delimiters := message.Delimiters
+	if delimiters == (Delimiters{}) {
+		delimiters = config.delimiters()
+	}
+
+	buffer := make([][]byte, 0, len(message.Records))
+	for _, record := range message.Records {
+		line := []byte(record.String(delimiters))
+		if charset != nil {
+			line = EncodeUTF8ToCharset(charset, line)
+		}
+		buffer = append(buffer, line)
+	}
+	return buffer, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +16,10 @@ import (
 /** Marshal - wrap datastructure to code
 **/
 func Marshal(message interface{}, enc Encoding, tz Timezone, notation Notation) ([][]byte, error) {
+	return MarshalWithConfig(message, Config{Encoding: enc, Timezone: tz, Notation: notation})
+}
+
+func MarshalWithConfig(message interface{}, config Config) ([][]byte, error) {
 
 	// dereference for as long as we deal with pointers
 	if reflect.TypeOf(message).Kind() == reflect.Ptr {
@@ -26,17 +31,21 @@ func Marshal(message interface{}, enc Encoding, tz Timezone, notation Notation)
 		return [][]byte{}, fmt.Errorf("can only marshal annotated structs (see readme)")
 	}
 
-	location, err := time.LoadLocation(string(tz))
+	location, err := config.location()
 	if err != nil {
 		return [][]byte{}, err
 	}
 
-	// default delmiters. These will be overwritten by the first occurence of "delimter"-annotation
-	repeatDelimiter := "\\"
-	componentDelimiter := "^"
-	escapeDelimiter := "&"
+	if config.Encoding == 0 {
+		config.Encoding = EncodingUTF8
+	}
+	if config.Notation == 0 {
+		config.Notation = StandardNotation
+	}
+
+	delimiters := config.delimiters()
 
-	buffer, err := iterateStructFieldsAndBuildOutput(message, 1, enc, location, notation, &repeatDelimiter, &componentDelimiter, &escapeDelimiter)
+	buffer, err := iterateStructFieldsAndBuildOutput(message, 1, config.Encoding, location, config.Notation, &delimiters)
 
 	return buffer, err
 }
@@ -49,7 +58,7 @@ type OutputRecord struct {
 type OutputRecords []OutputRecord
 
 func iterateStructFieldsAndBuildOutput(message interface{}, depth int, enc Encoding, location *time.Location, notation Notation,
-	repeatDelimiter, componentDelimiter, escapeDelimiter *string) ([][]byte, error) {
+	delimiters *Delimiters) ([][]byte, error) {
 
 	buffer := make([][]byte, 0)
 
@@ -66,9 +75,16 @@ func iterateStructFieldsAndBuildOutput(message interface{}, depth int, enc Encod
 
 			if currentRecord.Kind() == reflect.Slice { // array of something = iterate and recurse
 				for x := 0; x < currentRecord.Len(); x++ {
-					dood := currentRecord.Index(x).Interface()
+					element := currentRecord.Index(x)
+					if element.Kind() == reflect.Ptr { // []*Group, nil groups are absent
+						if element.IsNil() {
+							continue
+						}
+						element = element.Elem()
+					}
+					dood := element.Interface()
 
-					if bytes, err := iterateStructFieldsAndBuildOutput(dood, depth+1, enc, location, notation, repeatDelimiter, componentDelimiter, escapeDelimiter); err != nil {
+					if bytes, err := iterateStructFieldsAndBuildOutput(dood, depth+1, enc, location, notation, delimiters); err != nil {
 						return nil, err
 					} else {
 						for line := 0; line < len(bytes); line++ {
@@ -78,7 +94,7 @@ func iterateStructFieldsAndBuildOutput(message interface{}, depth int, enc Encod
 				}
 			} else if currentRecord.Kind() == reflect.Struct { // got the struct straignt = recurse directly
 
-				if bytes, err := iterateStructFieldsAndBuildOutput(currentRecord.Interface(), depth+1, enc, location, notation, repeatDelimiter, componentDelimiter, escapeDelimiter); err != nil {
+				if bytes, err := iterateStructFieldsAndBuildOutput(currentRecord.Interface(), depth+1, enc, location, notation, delimiters); err != nil {
 					return nil, err
 				} else {
 					for line := 0; line < len(bytes); line++ {
@@ -97,7 +113,14 @@ func iterateStructFieldsAndBuildOutput(message interface{}, depth int, enc Encod
 			if currentRecord.Kind() == reflect.Slice { // it is an annotated slice
 				if !currentRecord.IsNil() {
 					for x := 0; x < currentRecord.Len(); x++ {
-						outs, err := processOneRecord(recordType, currentRecord.Index(x), x+1, location, repeatDelimiter, componentDelimiter, escapeDelimiter) // fmt.Println(outp)
+						element := currentRecord.Index(x)
+						if element.Kind() == reflect.Ptr {
+							if element.IsNil() {
+								continue
+							}
+							element = element.Elem()
+						}
+						outs, err := processOneRecord(recordType, element, x+1, location, delimiters) // fmt.Println(outp)
 						if err != nil {
 							return nil, err
 						}
@@ -105,7 +128,13 @@ func iterateStructFieldsAndBuildOutput(message interface{}, depth int, enc Encod
 					}
 				}
 			} else {
-				outs, err := processOneRecord(recordType, currentRecord, 1, location, repeatDelimiter, componentDelimiter, escapeDelimiter) // fmt.Println(outp)
+				if currentRecord.Kind() == reflect.Ptr { // a nil record is absent
+					if currentRecord.IsNil() {
+						continue
+					}
+					currentRecord = currentRecord.Elem()
+				}
+				outs, err := processOneRecord(recordType, currentRecord, 1, location, delimiters) // fmt.Println(outp)
 				if err != nil {
 					return nil, err
 				}
@@ -165,12 +194,57 @@ func EncodeUTF8ToCharset(charmap *charmap.Charmap, data []byte) []byte {
 	return resultdata
 }
 
-func processOneRecord(recordType string, currentRecord reflect.Value, generatedSequenceNumber int, location *time.Location, repeatDelimiter, componentDelimiter, escapeDelimiter *string) (string, error) {
+func processOneRecord(recordType string, currentRecord reflect.Value, generatedSequenceNumber int, location *time.Location, delimiters *Delimiters) (string, error) {
 
 	if currentRecord.Kind() != reflect.Struct {
 		return "", nil // beeing not a struct is not an error
 	}
 
+	// delimiters declared in the record (header) apply to the whole message, including this record
+	for i := 0; i < currentRecord.NumField(); i++ {
+		field := currentRecord.Field(i)
+		fieldAstmTagsList := strings.Split(currentRecord.Type().Field(i).Tag.Get("astm"), ",")
+		if sliceContainsString(fieldAstmTagsList, ANNOTATION_DELIMITER) && field.Kind() == reflect.String && field.String() != "" {
+			value := field.String()
+			if len(value) >= 1 {
+				delimiters.Repeat = value[0:1]
+			}
+			if len(value) >= 2 {
+				delimiters.Component = value[1:2]
+			}
+			if len(value) >= 3 {
+				delimiters.Escape = value[2:3]
+			}
+		}
+	}
+
+	if marshaler := recordMarshalerOf(currentRecord); marshaler != nil {
+		record, err := marshaler.MarshalASTMRecord()
+		if err != nil {
+			return "", fmt.Errorf("invalid record %s : (%w)", currentRecord.Type().Name(), err)
+		}
+		if record == nil {
+			return "", fmt.Errorf("invalid record %s : (%w)", currentRecord.Type().Name(), ErrNoInput)
+		}
+		if len(record.Fields) == 0 {
+			record.Fields = []Field{{}}
+		}
+		record.Fields[0] = Field{Repeats: []Repeat{{Components: []string{recordType}}}}
+		return record.String(*delimiters), nil
+	}
+
+	// a record that was unmarshalled with a raw-annotated field is reproduced from the original,
+	// only the fields that changed since are replaced
+	raw := rawRecordOf(currentRecord)
+	var unchanged map[int]bool
+	var rawChanges []rawChange
+	if raw != "" {
+		if recordType == "H" && len(raw) >= 2 {
+			delimiters.Field = raw[1:2]
+		}
+		unchanged = unchangedFields(raw, currentRecord, location, recordType == "H", *delimiters)
+	}
+
 	fieldList := make(OutputRecords, 0)
 
 	for i := 0; i < currentRecord.NumField(); i++ {
@@ -183,68 +257,129 @@ func processOneRecord(recordType string, currentRecord reflect.Value, generatedS
 		}
 
 		fieldAstmTagsList := strings.Split(fieldAstmTag, ",")
+		if sliceContainsString(fieldAstmTagsList, ANNOTATION_RAW) {
+			continue
+		}
+		firstOutput := len(fieldList)
 
 		fieldIdx, repeatIdx, componentIdx, err := readFieldAddressAnnotation(fieldAstmTagsList[0])
 		if err != nil {
 			return "", fmt.Errorf("invalid annotation for field %s : (%w)", currentRecord.Type().Field(i).Name, err)
 		}
 
-		switch field.Type().Kind() {
-		case reflect.String:
-			value := ""
+		if field.Kind() == reflect.Ptr && !field.IsNil() {
+			field = field.Elem()
+		} else if field.Kind() == reflect.Ptr && sliceContainsString(fieldAstmTagsList, ANNOTATION_SEQUENCE) {
+			field = reflect.New(field.Type().Elem()).Elem() // sequence numbers are generated for nil as well
+		}
 
-			if sliceContainsString(fieldAstmTagsList, ANNOTATION_SEQUENCE) {
-				return "", fmt.Errorf("invalid annotation %s for string-field", ANNOTATION_SEQUENCE)
+		switch kind := field.Kind(); {
+		case kind == reflect.Ptr: // nil is an empty field
+			fieldList = addEmptyValues(fieldList, field.Type().Elem(), fieldIdx, repeatIdx, componentIdx)
+		case isComponentStruct(field.Type()):
+			if fieldList, err = marshalComponents(fieldList, field, fieldIdx, repeatIdx, location, *delimiters); err != nil {
+				return "", fmt.Errorf("invalid field %s in struct '%s', input not processed (%w)", currentRecord.Type().Field(i).Name, currentRecord.Type().Name(), err)
 			}
-
+		case isRepeatSlice(field): // every element is a repeat
+			for x := 0; x < field.Len(); x++ {
+				if isComponentStruct(field.Type().Elem()) {
+					if fieldList, err = marshalComponents(fieldList, field.Index(x), fieldIdx, repeatIdx+x, location, *delimiters); err != nil {
+						return "", fmt.Errorf("invalid field %s in struct '%s', input not processed (%w)", currentRecord.Type().Field(i).Name, currentRecord.Type().Name(), err)
+					}
+					continue
+				}
+				value, err := marshalValue(field.Index(x), fieldAstmTagsList, location)
+				if err != nil {
+					return "", fmt.Errorf("invalid field %s in struct '%s', input not processed (%w)", currentRecord.Type().Field(i).Name, currentRecord.Type().Name(), err)
+				}
+				fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx+x, componentIdx, escapeValue(value, *delimiters))
+			}
+		case kind == reflect.String && sliceContainsString(fieldAstmTagsList, ANNOTATION_DELIMITER):
 			// if no delimiters are given, default is \^&
-			if sliceContainsString(fieldAstmTagsList, ANNOTATION_DELIMITER) && field.String() == "" {
-				value = *repeatDelimiter + *componentDelimiter + *escapeDelimiter
-			} else {
-				value = field.String()
+			value := field.String()
+			if value == "" {
+				value = delimiters.Repeat + delimiters.Component + delimiters.Escape
 			}
-
 			fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx, componentIdx, value)
-		case reflect.Int:
-			value := fmt.Sprintf("%d", field.Int())
-			if sliceContainsString(fieldAstmTagsList, ANNOTATION_SEQUENCE) {
-				value = fmt.Sprintf("%d", generatedSequenceNumber)
-				generatedSequenceNumber = generatedSequenceNumber + 1
+		case kind == reflect.String && sliceContainsString(fieldAstmTagsList, ANNOTATION_SEQUENCE) && field.String() == "":
+			fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx, componentIdx, fmt.Sprintf("%d", generatedSequenceNumber))
+		case (isIntKind(kind) || isUintKind(kind)) && sliceContainsString(fieldAstmTagsList, ANNOTATION_SEQUENCE):
+			fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx, componentIdx, fmt.Sprintf("%d", generatedSequenceNumber))
+			generatedSequenceNumber = generatedSequenceNumber + 1
+		default:
+			value, err := marshalValue(field, fieldAstmTagsList, location)
+			if err != nil {
+				return "", fmt.Errorf("invalid field %s in struct '%s', input not processed (%w)", currentRecord.Type().Field(i).Name, currentRecord.Type().Name(), err)
 			}
+			fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx, componentIdx, escapeValue(value, *delimiters))
+		}
 
-			fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx, componentIdx, value)
-		case reflect.Float32:
-		case reflect.Float64:
-			//TODO: add annotation for decimal length
-			value := fmt.Sprintf("%.3f", field.Float())
-			fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx, componentIdx, value)
-		case reflect.Struct:
-			switch field.Type().Name() {
-			case "Time":
-				time := field.Interface().(time.Time)
-
-				if !time.IsZero() {
-
-					if sliceContainsString(fieldAstmTagsList, ANNOTATION_LONGDATE) {
-						value := time.In(location).Format("20060102150405")
-						fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx, componentIdx, value)
-					} else { // short date
-						value := time.In(location).Format("20060102")
-						fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx, componentIdx, value)
-					}
-				} else {
-					fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx, componentIdx, "")
-				}
-			default:
-				return "", fmt.Errorf("invalid field type '%s' in struct '%s', input not processed", field.Type().Name(), currentRecord.Type().Name())
-			}
-		default:
-			return "", fmt.Errorf("invalid field type '%s' in struct '%s', input not processed", field.Type().Name(), currentRecord.Type().Name())
+		if raw != "" && !unchanged[i] {
+			rawChanges = append(rawChanges, rawChange{
+				wholeField: !strings.Contains(fieldAstmTagsList[0], ".") && !hasComponentStructs(field.Type()),
+				repeats:    isRepeatSlice(field),
+				field:      fieldIdx,
+				values:     fieldList[firstOutput:],
+			})
 		}
+	}
 
+	if raw != "" {
+		return overlayRawRecord(raw, rawChanges, *delimiters), nil
 	}
 
-	return generateOutputRecord(recordType, fieldList, *repeatDelimiter, *componentDelimiter, *escapeDelimiter), nil
+	return generateOutputRecord(recordType, fieldList, *delimiters), nil
+}
+
+// marshalValue converts a field, component or repeat to its (unescaped) output
+func marshalValue(field reflect.Value, fieldAstmTagsList []string, location *time.Location) (string, error) {
+	switch kind := field.Kind(); {
+	case marshalerOf(field) != nil || textMarshalerOf(field) != nil:
+		return marshalCustomValue(field)
+	case kind == reflect.String:
+		return field.String(), checkAllowedValue(field, field.String())
+	case kind == reflect.Bool:
+		trueLiteral, falseLiteral := boolLiterals(fieldAstmTagsList)
+		if field.Bool() {
+			return trueLiteral, nil
+		}
+		return falseLiteral, nil
+	case isIntKind(kind):
+		return strconv.FormatInt(field.Int(), 10), nil
+	case isUintKind(kind):
+		return strconv.FormatUint(field.Uint(), 10), nil
+	case kind == reflect.Float32, kind == reflect.Float64:
+		return formatFloat(field.Float(), field.Type().Bits(), fieldAstmTagsList)
+	case field.Type() == timeType:
+		time := field.Interface().(time.Time)
+		if time.IsZero() {
+			return "", nil
+		}
+		if sliceContainsString(fieldAstmTagsList, ANNOTATION_LONGDATE) {
+			return time.In(location).Format("20060102150405"), nil
+		}
+		return time.In(location).Format("20060102"), nil // short date
+	case kind == reflect.Struct:
+		return "", fmt.Errorf("%w: the structure type '%s' is not implemented", ErrUnsupportedType, field.Type().Name())
+	default:
+		return "", fmt.Errorf("%w: the datatype '%s' is not implemented", ErrUnsupportedType, kind)
+	}
+}
+
+// formatFloat writes the number with the decimal places and the decimal separator set by the annotation
+func formatFloat(value float64, bitSize int, fieldAstmTagsList []string) (string, error) {
+	precision := 3
+	if option, ok := annotationOption(fieldAstmTagsList, ANNOTATION_PRECISION); ok {
+		var err error
+		if precision, err = strconv.Atoi(option); err != nil || precision < -1 {
+			return "", fmt.Errorf("%w: invalid precision '%s'", ErrInvalidAnnotation, option)
+		}
+	}
+	output := strconv.FormatFloat(value, 'f', precision, bitSize)
+	if hasDecimalComma(fieldAstmTagsList) {
+		output = strings.Replace(output, ".", ",", 1)
+	}
+	return output, nil
 }
 
 func addASTMFieldToList(data []OutputRecord, field, repeat, component int, value string) []OutputRecord {
@@ -287,7 +422,7 @@ func (or OutputRecords) Swap(i, j int) { or[i], or[j] = or[j], or[i] }
 	returns the full record for output to astm file
 */
 
-func generateOutputRecord(recordtype string, fieldList OutputRecords, REPEAT_DELIMITER, COMPONENT_DELIMITER, ESCAPE_DELMITER string) string {
+func generateOutputRecord(recordtype string, fieldList OutputRecords, delimiters Delimiters) string {
 
 	var output = ""
 
@@ -320,26 +455,29 @@ func generateOutputRecord(recordtype string, fieldList OutputRecords, REPEAT_DEL
 			if lastComponentIdx > -1 {
 				output += componentbuffer[0]
 				for i := 1; i <= lastComponentIdx; i++ {
-					output += COMPONENT_DELIMITER + componentbuffer[i]
+					output += delimiters.Component + componentbuffer[i]
 				}
 			}
 
 			if newFieldGroup {
 				if prevFieldGroup > 0 { // for the first iteration we don't know on which number the field numbering starts
 					for i := 0; i < currFieldGroup-prevFieldGroup; i++ {
-						output += "|"
+						output += delimiters.Field
 					}
 				} else {
-					output += "|"
+					output += delimiters.Field
 				}
 			} else if newRepeatGroup {
-				output += REPEAT_DELIMITER
+				output += delimiters.Repeat
 			}
 
 			componentbuffer = make([]string, 100)
 			lastComponentIdx = -1
 		}
 
+		if field.Component >= len(componentbuffer) { // annotations can address any component
+			componentbuffer = append(componentbuffer, make([]string, field.Component-len(componentbuffer)+1)...)
+		}
 		componentbuffer[field.Component] = field.Value
 
 		if field.Component > lastComponentIdx {
@@ -351,7 +489,7 @@ func generateOutputRecord(recordtype string, fieldList OutputRecords, REPEAT_DEL
 	if lastComponentIdx > -1 {
 		output += componentbuffer[0]
 		for i := 1; i <= lastComponentIdx; i++ {
-			output += COMPONENT_DELIMITER + componentbuffer[i]
+			output += delimiters.Component + componentbuffer[i]
 		}
 	}
 
@@ -0,0 +1,146 @@
+package lis2a2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path addresses a value in a Message, e.g. "R[2].3.4" for the 4th component of the 3rd field
+// of the second R record. The numbers after the record are the same as in the annotations:
+// "field", "field.component" or "field.repeat.component", all counting from 1.
+type Path struct {
+	RecordType string
+	Index      int // the n-th record of the type, 0 if not given (GetAll returns all records then)
+	Field      int
+	Repeat     int
+	Component  int
+}
+
+func ParsePath(path string) (Path, error) {
+	parsed := Path{}
+
+	separator := strings.Index(path, ".")
+	if separator < 1 {
+		return parsed, fmt.Errorf("%w '%s': expecting record type and field, e.g. \"P.6.1\"", ErrInvalidPath, path)
+	}
+	record, address := path[:separator], path[separator+1:]
+
+	if open := strings.Index(record, "["); open >= 0 {
+		if !strings.HasSuffix(record, "]") {
+			return parsed, fmt.Errorf("%w '%s': missing ']'", ErrInvalidPath, path)
+		}
+		index, err := strconv.Atoi(record[open+1 : len(record)-1])
+		if err != nil || index < 1 {
+			return parsed, fmt.Errorf("%w '%s': the index has to be a number starting with 1", ErrInvalidPath, path)
+		}
+		parsed.Index = index
+		record = record[:open]
+	}
+	if record == "" {
+		return parsed, fmt.Errorf("%w '%s': missing record type", ErrInvalidPath, path)
+	}
+	parsed.RecordType = record
+
+	field, repeat, component, err := readFieldAddressAnnotation(address)
+	if address == "" || err != nil || field < 0 || repeat < 0 || component < 0 || strings.Count(address, ".") > 2 {
+		return parsed, fmt.Errorf("%w '%s': invalid field address '%s'", ErrInvalidPath, path, address)
+	}
+	parsed.Field, parsed.Repeat, parsed.Component = field+1, repeat+1, component+1
+
+	return parsed, nil
+}
+
+func (p Path) String() string {
+	record := p.RecordType
+	if p.Index > 0 {
+		record = fmt.Sprintf("%s[%d]", p.RecordType, p.Index)
+	}
+	return fmt.Sprintf("%s.%d.%d.%d", record, p.Field, p.Repeat, p.Component)
+}
+
+// Get returns the value at the path. Without index the first record of the type is used. Parts beyond
+// the end of the record read as "", a record that does not exist is an error (ErrPathNotFound).
+func (m *Message) Get(path string) (string, error) {
+	parsed, err := ParsePath(path)
+	if err != nil {
+		return "", err
+	}
+	record := m.record(parsed)
+	if record == nil {
+		return "", fmt.Errorf("%w: %s", ErrPathNotFound, path)
+	}
+	return record.Field(parsed.Field).Repeat(parsed.Repeat).Component(parsed.Component), nil
+}
+
+// GetAll returns the value at the path for every record of the type (or only the one given by the index)
+func (m *Message) GetAll(path string) ([]string, error) {
+	parsed, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []string{}
+	count := 0
+	for _, record := range m.Records {
+		if record.Type() != parsed.RecordType {
+			continue
+		}
+		count++
+		if parsed.Index == 0 || parsed.Index == count {
+			values = append(values, record.Field(parsed.Field).Repeat(parsed.Repeat).Component(parsed.Component))
+		}
+	}
+	return values, nil
+}
+
+// Set changes the value at the path, the record is extended with empty fields, repeats and components as needed.
+// Without index the first record of the type is changed.
+func (m *Message) Set(path string, value string) error {
+	parsed, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	record := m.record(parsed)
+	if record == nil {
+		return fmt.Errorf("%w: %s", ErrPathNotFound, path)
+	}
+	if parsed.Field == 1 {
+		return fmt.Errorf("%w '%s': the record type can not be changed", ErrInvalidPath, path)
+	}
+	if parsed.RecordType == "H" && parsed.Field == 2 {
+		return fmt.Errorf("%w '%s': the delimiters are set in Message.Delimiters", ErrInvalidPath, path)
+	}
+
+	for len(record.Fields) < parsed.Field {
+		record.Fields = append(record.Fields, Field{Repeats: []Repeat{{Components: []string{""}}}})
+	}
+	field := &record.Fields[parsed.Field-1]
+	for len(field.Repeats) < parsed.Repeat {
+		field.Repeats = append(field.Repeats, Repeat{Components: []string{""}})
+	}
+	repeat := &field.Repeats[parsed.Repeat-1]
+	for len(repeat.Components) < parsed.Component {
+		repeat.Components = append(repeat.Components, "")
+	}
+	repeat.Components[parsed.Component-1] = value
+
+	return nil
+}
+
+func (m *Message) record(path Path) *Record {
+	index := path.Index
+	if index == 0 {
+		index = 1
+	}
+	count := 0
+	for _, record := range m.Records {
+		if record.Type() == path.RecordType {
+			count++
+			if count == index {
+				return record
+			}
+		}
+	}
+	return nil
+}
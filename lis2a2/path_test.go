@@ -0,0 +1,28 @@
+package lis2a2
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePath(t *testing.T) {
+	path, err := ParsePath("R[2].3.4")
+	assert.Nil(t, err)
+	assert.Equal(t, Path{RecordType: "R", Index: 2, Field: 3, Repeat: 1, Component: 4}, path)
+
+	path, err = ParsePath("P.6")
+	assert.Nil(t, err)
+	assert.Equal(t, Path{RecordType: "P", Field: 6, Repeat: 1, Component: 1}, path)
+
+	path, err = ParsePath("O.4.2.1")
+	assert.Nil(t, err)
+	assert.Equal(t, Path{RecordType: "O", Field: 4, Repeat: 2, Component: 1}, path)
+	assert.Equal(t, "O.4.2.1", path.String())
+
+	for _, invalid := range []string{"", "R", "R.", ".3", "R[0].3", "R[x].3", "R[2.3", "R.0", "R.a.b", "R.1.2.3.4"} {
+		_, err = ParsePath(invalid)
+		assert.True(t, errors.Is(err, ErrInvalidPath), invalid)
+	}
+}
@@ -0,0 +1,33 @@
+package lis2a2
+
+import (
+	"reflect"
+	"strings"
+)
+
+// isEmptyInput tells if the input a pointer field is mapped to is empty, in which case the pointer stays nil
+func isEmptyInput(fieldText string, repeat, component int, targetType reflect.Type, isHeader bool, delimiters Delimiters) bool {
+	switch {
+	case isHeader && targetType.Kind() == reflect.String: // header strings take the whole field
+		return fieldText == ""
+	case isComponentStruct(targetType):
+		repeats := strings.Split(fieldText, delimiters.Repeat)
+		return repeat >= len(repeats) || strings.Trim(repeats[repeat], delimiters.Component) == ""
+	case targetType.Kind() == reflect.Slice:
+		return fieldText == ""
+	default:
+		value, _ := extractAstmFieldByRepeatAndComponent(fieldText, repeat, component, delimiters, false)
+		return value == ""
+	}
+}
+
+// addEmptyValues adds the output of a nil pointer field: an empty value, for component structs one for every member
+func addEmptyValues(fieldList OutputRecords, targetType reflect.Type, fieldIdx, repeatIdx, componentIdx int) OutputRecords {
+	if !isComponentStruct(targetType) {
+		return addASTMFieldToList(fieldList, fieldIdx, repeatIdx, componentIdx, "")
+	}
+	for _, component := range memberComponents(targetType) {
+		fieldList = addASTMFieldToList(fieldList, fieldIdx, repeatIdx, component, "")
+	}
+	return fieldList
+}
@@ -0,0 +1,132 @@
+package lis2a2
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// rawChange holds the output of one struct field that differs from the raw record
+type rawChange struct {
+	wholeField bool // the annotation addresses the field, not a component
+	repeats    bool // the values are all repeats of the field (a slice)
+	field      int  // the field addressed by the annotation
+	values     OutputRecords
+}
+
+// rawRecordOf returns the content of the raw-annotated string field of the record, if any
+func rawRecordOf(record reflect.Value) string {
+	for i := 0; i < record.NumField(); i++ {
+		astmTagsList := strings.Split(record.Type().Field(i).Tag.Get("astm"), ",")
+		if sliceContainsString(astmTagsList, ANNOTATION_RAW) && record.Field(i).Kind() == reflect.String {
+			return record.Field(i).String()
+		}
+	}
+	return ""
+}
+
+// unchangedFields decodes the raw record into an empty copy of the record and returns the indexes of the
+// struct fields that still hold the decoded value. If the raw record can not be decoded all fields count as changed.
+func unchangedFields(raw string, record reflect.Value, location *time.Location, isHeader bool, delimiters Delimiters) map[int]bool {
+	unchanged := make(map[int]bool)
+
+	original := reflect.New(record.Type()).Elem()
+	if err := reflectAnnotatedFields(raw, original, location, isHeader, &delimiters, &decodeOptions{}); err != nil {
+		return unchanged
+	}
+
+	for i := 0; i < record.NumField(); i++ {
+		if !record.Field(i).CanInterface() {
+			continue
+		}
+		if originalTime, ok := original.Field(i).Interface().(time.Time); ok {
+			unchanged[i] = originalTime.Equal(record.Field(i).Interface().(time.Time))
+			continue
+		}
+		unchanged[i] = reflect.DeepEqual(original.Field(i).Interface(), record.Field(i).Interface())
+	}
+	return unchanged
+}
+
+// overlayRawRecord replaces the changed values in the raw record. Everything else, including values
+// no struct field is mapped to and the original escaping, is kept byte for byte.
+func overlayRawRecord(raw string, changes []rawChange, delimiters Delimiters) string {
+	fields := strings.Split(raw, delimiters.Field)
+
+	for _, change := range changes {
+		if change.repeats {
+			fields = overlayRepeats(fields, change, delimiters)
+			continue
+		}
+		for _, output := range change.values {
+			if output.Field >= len(fields) {
+				if output.Value == "" {
+					continue // nothing to add
+				}
+				fields = append(fields, make([]string, output.Field-len(fields)+1)...)
+			}
+			if change.wholeField {
+				fields[output.Field] = output.Value
+				continue
+			}
+			fields[output.Field] = replaceComponent(fields[output.Field], output.Repeat, output.Component, output.Value, delimiters)
+		}
+	}
+
+	return strings.Join(fields, delimiters.Field)
+}
+
+// replaceComponent sets one component of a field, keeping all other repeats and components
+func replaceComponent(field string, repeat, component int, value string, delimiters Delimiters) string {
+	repeats := strings.Split(field, delimiters.Repeat)
+	if repeat >= len(repeats) {
+		if value == "" {
+			return field
+		}
+		repeats = append(repeats, make([]string, repeat-len(repeats)+1)...)
+	}
+
+	components := strings.Split(repeats[repeat], delimiters.Component)
+	if component >= len(components) {
+		if value == "" {
+			return field
+		}
+		components = append(components, make([]string, component-len(components)+1)...)
+	}
+	components[component] = value
+
+	repeats[repeat] = strings.Join(components, delimiters.Component)
+	return strings.Join(repeats, delimiters.Repeat)
+}
+
+// overlayRepeats replaces the repeats of a field by the elements of a slice, surplus repeats of the raw record are removed
+func overlayRepeats(fields []string, change rawChange, delimiters Delimiters) []string {
+	field := change.field
+	if field >= len(fields) {
+		if len(change.values) == 0 {
+			return fields
+		}
+		fields = append(fields, make([]string, field-len(fields)+1)...)
+	}
+	if len(change.values) == 0 {
+		fields[field] = ""
+		return fields
+	}
+
+	repeats := strings.Split(fields[field], delimiters.Repeat)
+	repeatCount := change.values[len(change.values)-1].Repeat + 1
+	if len(repeats) < repeatCount {
+		repeats = append(repeats, make([]string, repeatCount-len(repeats))...)
+	}
+	repeats = repeats[:repeatCount]
+
+	for _, output := range change.values {
+		if change.wholeField {
+			repeats[output.Repeat] = output.Value
+			continue
+		}
+		repeats[output.Repeat] = replaceComponent(repeats[output.Repeat], 0, output.Component, output.Value, delimiters)
+	}
+	fields[field] = strings.Join(repeats, delimiters.Repeat)
+	return fields
+}
@@ -0,0 +1,15 @@
+package lis2a2
+
+import "reflect"
+
+// isRepeatSlice tells if the field is a slice receiving all repeats of the annotated field. Slices that
+// are custom field types themselves (e.g. implementing encoding.TextUnmarshaler) are mapped as one value.
+func isRepeatSlice(field reflect.Value) bool {
+	if field.Kind() != reflect.Slice {
+		return false
+	}
+	if field.CanAddr() {
+		return unmarshalerOf(field) == nil && textUnmarshalerOf(field) == nil
+	}
+	return marshalerOf(field) == nil && textMarshalerOf(field) == nil
+}
@@ -0,0 +1,81 @@
+package lis2a2
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldPosition addresses a value like the annotation does, counting from 0
+type fieldPosition struct {
+	field, repeat, component int
+}
+
+// findUnmappedValues returns an error for every non-empty value of the record that is not mapped by
+// any annotation of the struct. The header's fields are read as a whole, so a mapped field covers all of it.
+func findUnmappedValues(inputFields []string, recordType reflect.Type, isHeader bool, delimiters Delimiters) []*ParseError {
+	mapped := make(map[fieldPosition]bool)
+	mappedFields := make(map[int]bool)
+	mappedRepeats := make(map[fieldPosition]bool) // slices map a component in all repeats, the repeat is not used
+	for i := 0; i < recordType.NumField(); i++ {
+		astmTag := recordType.Field(i).Tag.Get("astm")
+		if astmTag == "" || sliceContainsString(strings.Split(astmTag, ","), ANNOTATION_RAW) {
+			continue
+		}
+		field, repeat, component, err := readFieldAddressAnnotation(strings.Split(astmTag, ",")[0])
+		if err != nil {
+			continue // reported when mapping the field
+		}
+		mappedFields[field] = true
+		components := []int{component}
+		if hasComponentStructs(recordType.Field(i).Type) {
+			components = memberComponents(recordType.Field(i).Type)
+		}
+		for _, component := range components {
+			mapped[fieldPosition{field, repeat, component}] = true
+			if recordType.Field(i).Type.Kind() == reflect.Slice {
+				mappedRepeats[fieldPosition{field, 0, component}] = true
+			}
+		}
+	}
+
+	var unmapped []*ParseError
+	for field := 1; field < len(inputFields); field++ { // the first field is the record type
+		if isHeader && mappedFields[field] {
+			continue
+		}
+		for repeat, repeatValue := range strings.Split(inputFields[field], delimiters.Repeat) {
+			for component, value := range strings.Split(repeatValue, delimiters.Component) {
+				if value == "" || mapped[fieldPosition{field, repeat, component}] || mappedRepeats[fieldPosition{field, 0, component}] {
+					continue
+				}
+				unmapped = append(unmapped, &ParseError{
+					Field:     field + 1,
+					Repeat:    repeat + 1,
+					Component: component + 1,
+					Value:     value,
+					Err:       fmt.Errorf("%w by the struct %s", ErrUnmappedValue, recordType.Name()),
+				})
+			}
+		}
+	}
+	return unmapped
+}
+
+// memberComponents returns the components mapped by the members of a component struct (or a slice or pointer of them)
+func memberComponents(fieldType reflect.Type) []int {
+	if fieldType.Kind() == reflect.Ptr || fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	var components []int
+	for i := 0; i < fieldType.NumField(); i++ {
+		astmTag := fieldType.Field(i).Tag.Get("astm")
+		if astmTag == "" {
+			continue
+		}
+		if component, _, err := readComponentAnnotation(astmTag); err == nil {
+			components = append(components, component)
+		}
+	}
+	return components
+}
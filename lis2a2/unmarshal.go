@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,47 +16,25 @@ import (
 const MAX_MESSAGE_COUNT = 44
 const MAX_DEPTH = 44
 
+// Unmarshal decodes the message with the given encoding and timezone, see UnmarshalWithConfig
 func Unmarshal(messageData []byte, targetStruct interface{}, enc Encoding, tz Timezone) error {
+	return UnmarshalWithConfig(messageData, targetStruct, Config{Encoding: enc, Timezone: tz})
+}
+
+func UnmarshalWithConfig(messageData []byte, targetStruct interface{}, config Config) error {
 	var (
 		messageBytes []byte
 		err          error
 	)
 
-	switch enc {
-	case EncodingUTF8:
-		messageBytes = messageData
-	case EncodingASCII:
+	charset, err := charmapOf(config.Encoding)
+	if err != nil {
+		return err
+	}
+	if charset == nil {
 		messageBytes = messageData
-	case EncodingDOS866:
-		if messageBytes, err = EncodeCharsetToUTF8From(charmap.CodePage866, messageData); err != nil {
-			return err
-		}
-	case EncodingDOS855:
-		if messageBytes, err = EncodeCharsetToUTF8From(charmap.CodePage855, messageData); err != nil {
-			return err
-		}
-	case EncodingDOS852:
-		if messageBytes, err = EncodeCharsetToUTF8From(charmap.CodePage852, messageData); err != nil {
-			return err
-		}
-	case EncodingWindows1250:
-		if messageBytes, err = EncodeCharsetToUTF8From(charmap.Windows1250, messageData); err != nil {
-			return err
-		}
-	case EncodingWindows1251:
-		if messageBytes, err = EncodeCharsetToUTF8From(charmap.Windows1251, messageData); err != nil {
-			return err
-		}
-	case EncodingWindows1252:
-		if messageBytes, err = EncodeCharsetToUTF8From(charmap.Windows1252, messageData); err != nil {
-			return err
-		}
-	case EncodingISO8859_1:
-		if messageBytes, err = EncodeCharsetToUTF8From(charmap.ISO8859_1, messageData); err != nil {
-			return err
-		}
-	default:
-		return fmt.Errorf("invalid Codepage Id='%d' - %w", enc, err)
+	} else if messageBytes, err = EncodeCharsetToUTF8From(charset, messageData); err != nil {
+		return err
 	}
 
 	// first try to break by 0x0a (non-standard, but used sometimes)
@@ -73,19 +52,122 @@ func Unmarshal(messageData []byte, targetStruct interface{}, enc Encoding, tz Ti
 		bufferedInputLinesWithEmptyLines[i] = strings.Trim(bufferedInputLinesWithEmptyLines[i], string([]byte{0x0D}))
 	}
 
-	// remove empty lines
+	// remove empty lines, remembering the original line numbers for errors
 	bufferedInputLines := []string{}
+	lineNumbers := []int{}
 	for i := range bufferedInputLinesWithEmptyLines {
 		if strings.Trim(bufferedInputLinesWithEmptyLines[i], " ") != "" {
 			bufferedInputLines = append(bufferedInputLines, bufferedInputLinesWithEmptyLines[i])
+			lineNumbers = append(lineNumbers, i+1)
 		}
 	}
 
+	return unmarshalLines(bufferedInputLines, lineNumbers, targetStruct, config)
+}
+
+// unmarshalLines maps the records (already converted to UTF8, without empty lines) to the struct.
+// lineNumbers holds the line of the input for every record and is used for the position in errors.
+func unmarshalLines(bufferedInputLines []string, lineNumbers []int, targetStruct interface{}, config Config) error {
+	options := &decodeOptions{lenient: config.Lenient, strict: config.Strict}
+
+	var unknownRecords ParseErrors
+	if config.Lenient || config.Strict {
+		bufferedInputLines, lineNumbers, unknownRecords = removeUnknownRecords(bufferedInputLines, lineNumbers, targetStruct)
+		if !config.Lenient && len(unknownRecords) > 0 {
+			return unknownRecords[0]
+		}
+	}
+
+	err := unmarshalRecords(bufferedInputLines, targetStruct, config, options)
+
+	// the positions refer to the records, turn them into lines of the input
+	var parseError *ParseError
+	if errors.As(err, &parseError) {
+		parseError.Line = inputLineNumber(parseError.Line, lineNumbers)
+	}
+	for _, collected := range options.errors {
+		collected.Line = inputLineNumber(collected.Line, lineNumbers)
+	}
+
+	allErrors := append(unknownRecords, options.errors...)
+	if err != nil {
+		if parseError == nil || len(allErrors) == 0 {
+			return err
+		}
+		// e.g. no record was left after removing the unknown ones, report that together with what was collected
+		allErrors = append(allErrors, parseError)
+	}
+	if len(allErrors) > 0 {
+		sort.SliceStable(allErrors, func(i, j int) bool { return allErrors[i].Line < allErrors[j].Line })
+		return allErrors
+	}
+	return nil
+}
+
+func inputLineNumber(recordNumber int, lineNumbers []int) int {
+	if recordNumber < 1 || len(lineNumbers) == 0 {
+		return recordNumber
+	}
+	if recordNumber > len(lineNumbers) { // after the last record
+		return lineNumbers[len(lineNumbers)-1] + recordNumber - len(lineNumbers)
+	}
+	return lineNumbers[recordNumber-1]
+}
+
+// removeUnknownRecords drops the records of a type that does not occur in the target struct at all
+func removeUnknownRecords(bufferedInputLines []string, lineNumbers []int, targetStruct interface{}) ([]string, []int, ParseErrors) {
+	knownRecordTypes := make(map[byte]bool)
+	collectRecordTypes(reflect.TypeOf(targetStruct), knownRecordTypes, 1)
+
 	var (
-		repeatDelimiter    = "\\"
-		componentDelimiter = "^"
-		escapeDelimiter    = "&"
+		knownLines       []string
+		knownLineNumbers []int
+		unknownRecords   ParseErrors
 	)
+	for i, line := range bufferedInputLines {
+		if !knownRecordTypes[line[0]] {
+			unknownRecord := recordError(fmt.Errorf("%w: record type '%c' is not part of the message", ErrUnexpectedRecord, line[0]), i, line)
+			unknownRecord.Line = inputLineNumber(i+1, lineNumbers)
+			unknownRecords = append(unknownRecords, unknownRecord)
+			continue
+		}
+		knownLines = append(knownLines, line)
+		if len(lineNumbers) > i {
+			knownLineNumbers = append(knownLineNumbers, lineNumbers[i])
+		}
+	}
+	return knownLines, knownLineNumbers, unknownRecords
+}
+
+func collectRecordTypes(structType reflect.Type, recordTypes map[byte]bool, depth int) {
+	for structType.Kind() == reflect.Ptr || structType.Kind() == reflect.Slice {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct || depth > MAX_DEPTH {
+		return
+	}
+	for i := 0; i < structType.NumField(); i++ {
+		astmTag := structType.Field(i).Tag.Get("astm")
+		if astmTag == "" {
+			collectRecordTypes(structType.Field(i).Type, recordTypes, depth+1)
+		} else {
+			recordTypes[astmTag[0]] = true
+		}
+	}
+}
+
+func unmarshalRecords(bufferedInputLines []string, targetStruct interface{}, config Config, options *decodeOptions) error {
+	if len(bufferedInputLines) == 0 {
+		return &ParseError{Err: ErrNoInput}
+	}
+
+	location, err := config.location()
+	if err != nil {
+		return err
+	}
+
+	// the delimiters are replaced by those from the header as soon as it is read
+	delimiters := config.delimiters()
 
 	currentInputLine := 0
 	currentInputLine, _, err = reflectInputToStruct(
@@ -93,11 +175,9 @@ func Unmarshal(messageData []byte, targetStruct interface{}, enc Encoding, tz Ti
 		1, /*recursion-depth*/
 		currentInputLine,
 		targetStruct,
-		enc,
-		tz,
-		&repeatDelimiter,
-		&componentDelimiter,
-		&escapeDelimiter)
+		location,
+		&delimiters,
+		options)
 
 	if err != nil {
 		return err
@@ -106,12 +186,45 @@ func Unmarshal(messageData []byte, targetStruct interface{}, enc Encoding, tz Ti
 	// if we have reached the end of the first message but not the end of our buffered input
 	if currentInputLine < len(bufferedInputLines) {
 		// return an error to avoid data loss
-		return fmt.Errorf("%d lines of input were skipped. Last line was %d: '%s' ", len(bufferedInputLines)-currentInputLine+1, currentInputLine, bufferedInputLines[currentInputLine])
+		err := recordError(fmt.Errorf("%w: %d line(s) after the end of the message", ErrSkippedInput, len(bufferedInputLines)-currentInputLine),
+			currentInputLine, bufferedInputLines[currentInputLine])
+		if options.collect(err, currentInputLine, bufferedInputLines[currentInputLine]) {
+			return nil
+		}
+		return err
 	}
 
 	return nil
 }
 
+// decodeOptions are the settings of Config that change how the input is mapped to the structs
+type decodeOptions struct {
+	lenient bool
+	strict  bool
+	errors  ParseErrors // collected in lenient mode
+}
+
+// collect records the error in lenient mode, in which case the decoding can go on
+func (o *decodeOptions) collect(err error, lineIndex int, line string) bool {
+	if !o.lenient || isSchemaError(err) {
+		return false
+	}
+	var fieldErrors ParseErrors
+	if errors.As(err, &fieldErrors) {
+		for _, fieldError := range fieldErrors {
+			o.errors = append(o.errors, recordError(fieldError, lineIndex, line))
+		}
+		return true
+	}
+	o.errors = append(o.errors, recordError(err, lineIndex, line))
+	return true
+}
+
+// isSchemaError tells errors in the annotation of the structs, which are never tolerated
+func isSchemaError(err error) bool {
+	return errors.Is(err, ErrInvalidAnnotation) || errors.Is(err, ErrUnsupportedType) || errors.Is(err, ErrMaxDepth)
+}
+
 type RETV int
 
 const (
@@ -132,16 +245,16 @@ func EncodeCharsetToUTF8From(charmap *charmap.Charmap, data []byte) ([]byte, err
 }
 
 /* This function takes a string and a struct and matches the annotated fields to the string-input */
-func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLine int, targetStruct interface{}, enc Encoding, tz Timezone,
-	repeatDelimiter, componentDelimiter, escapeDelimiter *string) (int, RETV, error) {
+func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLine int, targetStruct interface{}, timeLocation *time.Location,
+	delimiters *Delimiters, options *decodeOptions) (int, RETV, error) {
 
 	if depth > MAX_DEPTH {
-		return currentInputLine, ERROR, errors.New(fmt.Sprintf("Maximum recursion depth reached (%d). Too many nested structures ? - aborting", depth))
+		return currentInputLine, ERROR, recordError(fmt.Errorf("%w (%d). Too many nested structures ? - aborting", ErrMaxDepth, depth), currentInputLine, bufferedInputLines[currentInputLine])
 	}
 
 	if bufferedInputLines[currentInputLine] == "" {
 		// Caution : +1 might skip one; .. without could stick in loop
-		return currentInputLine + 1, UNEXPECTED, errors.New(fmt.Sprintf("Empty Input"))
+		return currentInputLine + 1, UNEXPECTED, recordError(ErrNoInput, currentInputLine, "")
 	}
 
 	var targetStructType reflect.Type
@@ -153,10 +266,8 @@ func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLi
 		targetStructType = reflect.TypeOf(targetStruct).Elem()
 		targetStructValue = reflect.ValueOf(targetStruct).Elem()
 	}
-	timeLocation, err := time.LoadLocation(string(tz))
-	if err != nil {
-		return currentInputLine, ERROR, err
-	}
+	var err error
+	startInputLine := currentInputLine
 
 	for i := 0; i < targetStructType.NumField(); i++ {
 		currentRecord := targetStructValue.Field(i)
@@ -178,6 +289,10 @@ func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLi
 				if reflect.TypeOf(targetStructValue.Interface()).Kind() == reflect.Struct {
 
 					innerStructureType := targetStructType.Field(i).Type.Elem()
+					isPointerSlice := innerStructureType.Kind() == reflect.Ptr // []*Group
+					if isPointerSlice {
+						innerStructureType = innerStructureType.Elem()
+					}
 
 					sliceForNestedStructure := reflect.MakeSlice(targetStructType.Field(i).Type, 0, 0)
 
@@ -185,8 +300,9 @@ func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLi
 						allocatedElement := reflect.New(innerStructureType)
 						var err error
 						var retv RETV
+						elementInputLine := currentInputLine
 						currentInputLine, retv, err = reflectInputToStruct(bufferedInputLines, depth+1,
-							currentInputLine, allocatedElement.Interface(), enc, tz, repeatDelimiter, componentDelimiter, escapeDelimiter)
+							currentInputLine, allocatedElement.Interface(), timeLocation, delimiters, options)
 
 						if err != nil {
 							if retv == UNEXPECTED {
@@ -196,8 +312,15 @@ func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLi
 								return currentInputLine, ERROR, err
 							}
 						}
+						if currentInputLine == elementInputLine {
+							break // nothing matched (e.g. only optional records), the next field has to deal with the input
+						}
 
-						sliceForNestedStructure = reflect.Append(sliceForNestedStructure, allocatedElement.Elem())
+						if isPointerSlice {
+							sliceForNestedStructure = reflect.Append(sliceForNestedStructure, allocatedElement)
+						} else {
+							sliceForNestedStructure = reflect.Append(sliceForNestedStructure, allocatedElement.Elem())
+						}
 						reflect.ValueOf(targetStruct).Elem().Field(i).Set(sliceForNestedStructure)
 					}
 					continue
@@ -209,8 +332,7 @@ func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLi
 
 				dood := currentRecord.Addr().Interface()
 
-				currentInputLine, retv, err = reflectInputToStruct(bufferedInputLines, depth+1, currentInputLine, dood, enc, tz,
-					repeatDelimiter, componentDelimiter, escapeDelimiter)
+				currentInputLine, retv, err = reflectInputToStruct(bufferedInputLines, depth+1, currentInputLine, dood, timeLocation, delimiters, options)
 				if err != nil {
 					if retv == UNEXPECTED {
 						if depth > 0 {
@@ -229,7 +351,7 @@ func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLi
 				continue
 
 			} else {
-				return currentInputLine, ERROR, errors.New(fmt.Sprintf("Invalid Datatype '%s' - abort unmarshal.", targetStructType.Field(i).Type.Kind()))
+				return currentInputLine, ERROR, &ParseError{FieldName: ftype.Name, Err: fmt.Errorf("%w '%s' without annotation - abort unmarshal", ErrUnsupportedType, ftype.Type.Kind())}
 			}
 		}
 
@@ -240,7 +362,12 @@ func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLi
 		}
 
 		if currentInputLine >= len(bufferedInputLines) { // premature end ...
-			return currentInputLine, ERROR, fmt.Errorf("premature end of input in line %d (Missing Data)", currentInputLine)
+			err := &ParseError{Line: currentInputLine + 1, RecordType: string(expectInputRecordType), Err: ErrPrematureEnd}
+			if options.lenient {
+				options.errors = append(options.errors, err)
+				break
+			}
+			return currentInputLine, ERROR, err
 		}
 
 		if len(bufferedInputLines[currentInputLine]) == 0 {
@@ -258,30 +385,46 @@ func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLi
 			//Special case: its not an anotated record, it is an array of annotated records here :
 			if currentRecord.Kind() == reflect.Slice {
 				innerStructureType := targetStructType.Field(i).Type.Elem()
+				isPointerSlice := innerStructureType.Kind() == reflect.Ptr // []*Record
+				if isPointerSlice {
+					innerStructureType = innerStructureType.Elem()
+				}
 				sliceForNestedStructure := reflect.MakeSlice(targetStructType.Field(i).Type, 0, 0)
 				for { // iterate for as long as the same type repeats
 					allocatedElement := reflect.New(innerStructureType)
 
-					if err = reflectAnnotatedFields(bufferedInputLines[currentInputLine], allocatedElement.Elem(), timeLocation, isHeader, repeatDelimiter, componentDelimiter, escapeDelimiter); err != nil {
-						return currentInputLine, ERROR, errors.New(fmt.Sprintf("Failed to process input line '%s' err:%s", bufferedInputLines[currentInputLine], err))
+					if err = reflectAnnotatedFields(bufferedInputLines[currentInputLine], allocatedElement.Elem(), timeLocation, isHeader, delimiters, options); err != nil {
+						if !options.collect(err, currentInputLine, bufferedInputLines[currentInputLine]) {
+							return currentInputLine, ERROR, recordError(err, currentInputLine, bufferedInputLines[currentInputLine])
+						}
 					}
 
-					sliceForNestedStructure = reflect.Append(sliceForNestedStructure, allocatedElement.Elem())
+					if isPointerSlice {
+						sliceForNestedStructure = reflect.Append(sliceForNestedStructure, allocatedElement)
+					} else {
+						sliceForNestedStructure = reflect.Append(sliceForNestedStructure, allocatedElement.Elem())
+					}
 					reflect.ValueOf(targetStruct).Elem().Field(i).Set(sliceForNestedStructure)
 
 					// keep reading while same elements are up
 					currentInputLine = currentInputLine + 1
-					if expectInputRecordType != bufferedInputLines[currentInputLine][0] {
+					if currentInputLine >= len(bufferedInputLines) {
 						break
 					}
-					if currentInputLine >= len(bufferedInputLines) {
+					if expectInputRecordType != bufferedInputLines[currentInputLine][0] {
 						break
 					}
 				}
 
 			} else { // The "normal" case: scanning a string into a structure :
-				if err = reflectAnnotatedFields(bufferedInputLines[currentInputLine], currentRecord, timeLocation, isHeader, repeatDelimiter, componentDelimiter, escapeDelimiter); err != nil {
-					return currentInputLine, ERROR, errors.New(fmt.Sprintf("Failed to process input line '%s' err:%s", bufferedInputLines[currentInputLine], err))
+				if currentRecord.Kind() == reflect.Ptr { // *Record stays nil as long as the record is absent
+					currentRecord.Set(reflect.New(currentRecord.Type().Elem()))
+					currentRecord = currentRecord.Elem()
+				}
+				if err = reflectAnnotatedFields(bufferedInputLines[currentInputLine], currentRecord, timeLocation, isHeader, delimiters, options); err != nil {
+					if !options.collect(err, currentInputLine, bufferedInputLines[currentInputLine]) {
+						return currentInputLine, ERROR, recordError(err, currentInputLine, bufferedInputLines[currentInputLine])
+					}
 				}
 				currentInputLine = currentInputLine + 1
 			}
@@ -290,7 +433,13 @@ func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLi
 			if expectedInputRecordTypeOptional {
 				continue // skipping optional record instead of an error
 			} else {
-				return currentInputLine, UNEXPECTED, errors.New(fmt.Sprintf("Expected Record-Type '%c' input was '%c' in depth (%d) (Abort)", expectInputRecordType, bufferedInputLines[currentInputLine][0], depth))
+				err := recordError(fmt.Errorf("%w: expected record type '%c' in depth (%d)", ErrUnexpectedRecord, expectInputRecordType, depth),
+					currentInputLine, bufferedInputLines[currentInputLine])
+				// in lenient mode a missing record is skipped, unless it ends a sequence of (nested) structures
+				if (depth == 1 || currentInputLine > startInputLine) && options.collect(err, currentInputLine, bufferedInputLines[currentInputLine]) {
+					continue
+				}
+				return currentInputLine, UNEXPECTED, err
 			}
 		}
 
@@ -303,183 +452,282 @@ func reflectInputToStruct(bufferedInputLines []string, depth int, currentInputLi
 }
 
 func reflectAnnotatedFields(inputStr string, record reflect.Value, timezone *time.Location, isHeader bool,
-	repeatDelimiter, componentDelimiter, escapeDelimiter *string) error {
+	delimiters *Delimiters, options *decodeOptions) error {
 
 	if reflect.ValueOf(record).Type().Kind() != reflect.Struct {
-		return errors.New(fmt.Sprintf("invalid type of target: '%s', expecting 'struct'", reflect.ValueOf(record).Type().Kind()))
+		return fmt.Errorf("%w of target: '%s', expecting 'struct'", ErrUnsupportedType, reflect.ValueOf(record).Type().Kind())
+	}
+
+	// the character following the record type of the header is the field delimiter
+	if isHeader && len(inputStr) >= 2 {
+		delimiters.Field = inputStr[1:2]
 	}
 
-	inputFields := strings.Split(inputStr, "|")
+	// records decoding themselves get the record split by the delimiters instead of the annotated fields
+	if unmarshaler := recordUnmarshalerOf(record); unmarshaler != nil {
+		if isHeader {
+			*delimiters = headerDelimiters(inputStr, *delimiters)
+		}
+		if err := unmarshaler.UnmarshalASTMRecord(parseRecord(inputStr, *delimiters)); err != nil {
+			return &ParseError{Value: inputStr, Err: fmt.Errorf("%w: %s", ErrInvalidValue, err)}
+		}
+		return nil
+	}
+
+	inputFields := strings.Split(inputStr, delimiters.Field)
 	if len(inputFields) < 1 {
-		return errors.New("Input contains no data")
+		return ErrNoInput
 	}
 
+	var fieldErrors ParseErrors
 	for j := 0; j < record.NumField(); j++ {
-		recordfield := record.Field(j)
-		if !recordfield.CanInterface() {
-			return errors.New(fmt.Sprintf("Field %s is not exported - aborting import", recordfield.Type().Name()))
+		if err := reflectAnnotatedField(inputFields, record, j, timezone, isHeader, delimiters, options.strict); err != nil {
+			var parseError *ParseError
+			if options.lenient && errors.As(err, &parseError) && !isSchemaError(err) {
+				fieldErrors = append(fieldErrors, parseError) // the field stays empty
+				continue
+			}
+			return err
 		}
-		recordFieldInterface := recordfield.Addr().Interface()
+	}
 
-		hasOverrideDelimiterAnnotation := false
-		inputIsRequired := false
-		astmTag := record.Type().Field(j).Tag.Get("astm")
-		if astmTag == "" {
-			continue // nothing to process when someone requires astm:
-		}
-		astmTagsList := strings.Split(astmTag, ",")
-		for i := 0; i < len(astmTagsList); i++ {
-			astmTagsList[i] = strings.Trim(astmTagsList[i], " ")
-		}
-		if sliceContainsString(astmTagsList, ANNOTATION_DELIMITER) {
-			// the delimiter is instantly replaced with the delimiters from the file for further parsing. By default that is "\^&"
-			hasOverrideDelimiterAnnotation = true
+	if options.strict {
+		for _, unmapped := range findUnmappedValues(inputFields, record.Type(), isHeader, *delimiters) {
+			if !options.lenient {
+				return unmapped
+			}
+			fieldErrors = append(fieldErrors, unmapped)
 		}
-		if sliceContainsString(astmTagsList, ANNOTATION_REQUIRED) {
-			inputIsRequired = true
+	}
+
+	if len(fieldErrors) > 0 {
+		return fieldErrors
+	}
+	return nil
+}
+
+// reflectAnnotatedField maps the input to the j-th field of the record
+func reflectAnnotatedField(inputFields []string, record reflect.Value, j int, timezone *time.Location, isHeader bool,
+	delimiters *Delimiters, strict bool) error {
+
+	recordfield := record.Field(j)
+	if !recordfield.CanInterface() {
+		return &ParseError{FieldName: record.Type().Field(j).Name, Err: fmt.Errorf("%w: field is not exported - aborting import", ErrUnsupportedType)}
+	}
+
+	hasOverrideDelimiterAnnotation := false
+	inputIsRequired := false
+	astmTag := record.Type().Field(j).Tag.Get("astm")
+	if astmTag == "" {
+		return nil // nothing to process when someone requires astm:
+	}
+	astmTagsList := strings.Split(astmTag, ",")
+	for i := 0; i < len(astmTagsList); i++ {
+		astmTagsList[i] = strings.Trim(astmTagsList[i], " ")
+	}
+	if sliceContainsString(astmTagsList, ANNOTATION_DELIMITER) {
+		// the delimiter is instantly replaced with the delimiters from the file for further parsing. By default that is "\^&"
+		hasOverrideDelimiterAnnotation = true
+	}
+	if sliceContainsString(astmTagsList, ANNOTATION_REQUIRED) {
+		inputIsRequired = true
+	}
+	if sliceContainsString(astmTagsList, ANNOTATION_RAW) {
+		// the whole record as received, used by Marshal to reproduce the unchanged parts
+		if recordfield.Kind() != reflect.String {
+			return &ParseError{FieldName: record.Type().Field(j).Name, Err: fmt.Errorf("%w: raw-annotation is only allowed for string-type, not %s", ErrInvalidAnnotation, recordfield.Kind())}
 		}
-		currentInputFieldNo, repeat, component, err := readFieldAddressAnnotation(astmTagsList[0])
-		if err != nil {
-			return errors.New(fmt.Sprintf("Invalid annotation for field %s. (%s)", record.Type().Field(j).Name, err))
+		recordfield.SetString(strings.Join(inputFields, delimiters.Field))
+		return nil
+	}
+	currentInputFieldNo, repeat, component, err := readFieldAddressAnnotation(astmTagsList[0])
+	if err != nil {
+		return &ParseError{FieldName: record.Type().Field(j).Name, Value: astmTag, Err: fmt.Errorf("%w: %s", ErrInvalidAnnotation, err)}
+	}
+	if currentInputFieldNo >= len(inputFields) || currentInputFieldNo < 0 {
+		if strict && inputIsRequired {
+			return &ParseError{Field: currentInputFieldNo + 1, Repeat: repeat + 1, Component: component + 1,
+				FieldName: record.Type().Field(j).Name, Err: fmt.Errorf("%w: the record has only %d fields", ErrMissingValue, len(inputFields))}
 		}
-		if currentInputFieldNo >= len(inputFields) || currentInputFieldNo < 0 {
-			//TODO: user should be able to toggle wether he wants an exact match = error or bestfit = skip silent
-			continue // mapped field is beyond the data
+		return nil // mapped field is beyond the data, bestfit unless strict
+	}
+
+	// fieldError attaches the position of the field to an error
+	fieldError := func(err error) *ParseError {
+		return &ParseError{
+			Field:     currentInputFieldNo + 1,
+			Repeat:    repeat + 1,
+			Component: component + 1,
+			FieldName: record.Type().Field(j).Name,
+			Value:     inputFields[currentInputFieldNo],
+			Err:       err,
 		}
+	}
 
-		switch reflect.TypeOf(recordfield.Interface()).Kind() {
-		case reflect.String:
-			if value, err := extractAstmFieldByRepeatAndComponent(inputFields[currentInputFieldNo],
-				repeat, component, *repeatDelimiter, *componentDelimiter, sliceContainsString(astmTagsList, ANNOTATION_REQUIRED)); err == nil {
+	if recordfield.Kind() == reflect.Ptr { // nil stands for an empty field
+		if isEmptyInput(inputFields[currentInputFieldNo], repeat, component, recordfield.Type().Elem(), isHeader, *delimiters) {
+			if strict && inputIsRequired {
+				return fieldError(fmt.Errorf("%w: the field is empty", ErrMissingValue))
+			}
+			recordfield.Set(reflect.Zero(recordfield.Type()))
+			return nil
+		}
+		recordfield.Set(reflect.New(recordfield.Type().Elem()))
+		recordfield = recordfield.Elem()
+	}
 
-				// in headers there can be special characters, that is why the value needs to disregard the delimiters:
-				if isHeader {
-					value = inputFields[currentInputFieldNo]
-				}
+	if hasOverrideDelimiterAnnotation && recordfield.Kind() != reflect.String {
+		return fieldError(fmt.Errorf("%w: delimiter-annotation is only allowed for string-type, not %s", ErrInvalidAnnotation, recordfield.Type()))
+	}
 
-				reflect.ValueOf(recordFieldInterface).Elem().SetString(reflect.ValueOf(value).String())
+	if isComponentStruct(recordfield.Type()) {
+		if failedComponent, err := unmarshalComponents(recordfield, inputFields[currentInputFieldNo], repeat, *delimiters, timezone, strict); err != nil {
+			parseError := fieldError(err)
+			parseError.Component = failedComponent + 1
+			return parseError
+		}
+		return nil
+	}
 
-				if hasOverrideDelimiterAnnotation { // the first three characters become the new delimiters
-					if len(value) >= 1 {
-						*repeatDelimiter = value[0:1]
-					}
-					if len(value) >= 2 {
-						*componentDelimiter = value[1:2]
-					}
-					if len(value) >= 3 {
-						*escapeDelimiter = value[2:3]
-					}
+	if isRepeatSlice(recordfield) { // all repeats of the field, starting with the annotated one
+		if inputFields[currentInputFieldNo] == "" {
+			if inputIsRequired {
+				return fieldError(fmt.Errorf("%w: the field has no repeats", ErrMissingValue))
+			}
+			return nil
+		}
+		repeatCount := len(strings.Split(inputFields[currentInputFieldNo], delimiters.Repeat))
+		elements := reflect.MakeSlice(recordfield.Type(), 0, repeatCount)
+		for r := repeat; r < repeatCount; r++ {
+			element := reflect.New(recordfield.Type().Elem()).Elem()
+			if isComponentStruct(element.Type()) {
+				if failedComponent, err := unmarshalComponents(element, inputFields[currentInputFieldNo], r, *delimiters, timezone, strict); err != nil {
+					parseError := fieldError(err)
+					parseError.Repeat = r + 1
+					parseError.Component = failedComponent + 1
+					return parseError
 				}
 			} else {
-				if inputIsRequired { // by default we ignore missing input
-					return errors.New(fmt.Sprintf("Failed to extract index (%d.%d.%d) from input line '%s' : (%s)",
-						currentInputFieldNo+1, repeat+1, component+1, inputStr, err))
+				value, _ := extractAstmFieldByRepeatAndComponent(inputFields[currentInputFieldNo], r, component, *delimiters, false)
+				if err := unmarshalValue(element, value, astmTagsList, timezone); err != nil {
+					parseError := fieldError(err)
+					parseError.Repeat = r + 1
+					return parseError
 				}
 			}
-		case reflect.Int:
-			if hasOverrideDelimiterAnnotation {
-				return errors.New("delimiter-annotation is only allowed for string-type, not int.")
-			}
-
-			if value, err := extractAstmFieldByRepeatAndComponent(inputFields[currentInputFieldNo], repeat, component,
-				*repeatDelimiter, *componentDelimiter, sliceContainsString(astmTagsList, ANNOTATION_REQUIRED)); err == nil {
+			elements = reflect.Append(elements, element)
+		}
+		recordfield.Set(elements)
+		return nil
+	}
 
-				if num, err := strconv.Atoi(value); err == nil {
-					reflect.ValueOf(recordFieldInterface).Elem().Set(reflect.ValueOf(num))
-				} else {
-					if inputIsRequired { // by default we ignore missing input
-						return errors.New(fmt.Sprintf("Failed to extract index (%d,%d) from field %s(%s)", repeat, component, inputFields[currentInputFieldNo], err))
-					}
-				}
+	value, err := extractAstmFieldByRepeatAndComponent(inputFields[currentInputFieldNo], repeat, component, *delimiters, inputIsRequired)
+	if err != nil {
+		return fieldError(err)
+	}
 
-			} else {
-				return err
-			}
-		case reflect.Float32:
-			if hasOverrideDelimiterAnnotation {
-				return errors.New("delimiter-annotation is only allowed for string-type, not int.")
-			}
+	// in headers there can be special characters, that is why the value needs to disregard the delimiters:
+	if isHeader && recordfield.Kind() == reflect.String {
+		value = inputFields[currentInputFieldNo]
+		if !hasOverrideDelimiterAnnotation {
+			value = unescapeValue(value, *delimiters)
+		}
+	}
 
-			if value, err := extractAstmFieldByRepeatAndComponent(inputFields[currentInputFieldNo],
-				repeat, component, *repeatDelimiter, *componentDelimiter,
-				sliceContainsString(astmTagsList, ANNOTATION_REQUIRED)); err == nil {
+	if strict && inputIsRequired && value == "" { // an empty field is how a missing value is sent
+		return fieldError(fmt.Errorf("%w: the field is empty", ErrMissingValue))
+	}
 
-				if num, err := strconv.ParseFloat(value, 32); err == nil {
-					reflect.ValueOf(recordFieldInterface).Elem().Set(reflect.ValueOf(float32(num)))
-				} else {
-					if inputIsRequired { // by default we ignore missing input
-						return errors.New(fmt.Sprintf("Failed to extract index (%d,%d) from field %s(%s)", repeat, component, inputFields[currentInputFieldNo], err))
-					}
-				}
+	if err := unmarshalValue(recordfield, value, astmTagsList, timezone); err != nil {
+		return fieldError(err)
+	}
 
-			} else {
-				return err
-			}
-		case reflect.Float64:
-			if hasOverrideDelimiterAnnotation {
-				return errors.New("delimiter-annotation is only allowed for string-type, not int.")
-			}
+	if hasOverrideDelimiterAnnotation { // the first three characters become the new delimiters
+		if len(value) >= 1 {
+			delimiters.Repeat = value[0:1]
+		}
+		if len(value) >= 2 {
+			delimiters.Component = value[1:2]
+		}
+		if len(value) >= 3 {
+			delimiters.Escape = value[2:3]
+		}
+	}
 
-			if value, err := extractAstmFieldByRepeatAndComponent(inputFields[currentInputFieldNo],
-				repeat, component, *repeatDelimiter, *componentDelimiter,
-				sliceContainsString(astmTagsList, ANNOTATION_REQUIRED)); err == nil {
+	return nil
+}
 
-				if num, err := strconv.ParseFloat(value, 64); err == nil {
-					reflect.ValueOf(recordFieldInterface).Elem().Set(reflect.ValueOf(float64(num)))
-				} else {
-					if inputIsRequired { // by default we ignore missing input
-						return errors.New(fmt.Sprintf("Failed to extract index (%d,%d) from field %s(%s)", repeat, component, inputFields[currentInputFieldNo], err))
-					}
-				}
+// unmarshalValue converts the value of a field, component or repeat to the type of the target
+func unmarshalValue(target reflect.Value, value string, astmTagsList []string, timezone *time.Location) error {
+	isRequired := sliceContainsString(astmTagsList, ANNOTATION_REQUIRED)
 
-			} else {
-				return err
+	switch kind := target.Kind(); {
+	case target.Type() == decimalType:
+		if err := unmarshalCustomValue(target, value); err != nil && isRequired { // by default we ignore invalid input, e.g. "<0.1"
+			return fmt.Errorf("%w: %s", ErrInvalidValue, err)
+		}
+	case unmarshalerOf(target) != nil || textUnmarshalerOf(target) != nil:
+		if err := unmarshalCustomValue(target, value); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidValue, err)
+		}
+	case kind == reflect.String:
+		if err := checkAllowedValue(target, value); err != nil {
+			return err
+		}
+		target.SetString(value)
+	case kind == reflect.Bool:
+		trueLiteral, falseLiteral := boolLiterals(astmTagsList)
+		if value == trueLiteral {
+			target.SetBool(true)
+		} else if value == falseLiteral || value == "" {
+			target.SetBool(false)
+		} else if isRequired { // by default we ignore unknown input
+			return fmt.Errorf("%w: expecting '%s' or '%s', not '%s'", ErrInvalidValue, trueLiteral, falseLiteral, value)
+		}
+	case isIntKind(kind):
+		if num, err := strconv.ParseInt(value, 10, target.Type().Bits()); err == nil {
+			target.SetInt(num)
+		} else if isRequired { // by default we ignore missing input
+			return fmt.Errorf("%w: %s", ErrInvalidValue, err)
+		}
+	case isUintKind(kind):
+		if num, err := strconv.ParseUint(value, 10, target.Type().Bits()); err == nil {
+			target.SetUint(num)
+		} else if isRequired { // by default we ignore missing input
+			return fmt.Errorf("%w: %s", ErrInvalidValue, err)
+		}
+	case kind == reflect.Float32, kind == reflect.Float64:
+		if hasDecimalComma(astmTagsList) {
+			value = strings.Replace(value, ",", ".", 1)
+		}
+		if num, err := strconv.ParseFloat(value, target.Type().Bits()); err == nil {
+			target.SetFloat(num)
+		} else if isRequired { // by default we ignore missing input
+			return fmt.Errorf("%w: %s", ErrInvalidValue, err)
+		}
+	case target.Type() == timeType:
+		if value == "" {
+			target.Set(reflect.ValueOf(time.Time{}))
+		} else if len(value) == 8 { // YYYYMMDD See Section 5.6.2 https://samson-rus.com/wp-content/files/LIS2-A2.pdf
+			timeInLocation, err := time.ParseInLocation("20060102", value, timezone)
+			if err != nil {
+				return fmt.Errorf("%w: invalid time format <%s>", ErrInvalidValue, value)
 			}
-
-		case reflect.Struct:
-			switch reflect.TypeOf(recordfield.Interface()).Name() {
-			case "Time":
-				if hasOverrideDelimiterAnnotation {
-					return errors.New("delimiter-annotation is only allowed for string-type, not Time")
-				}
-
-				var inputFieldValue string
-				if value, err := extractAstmFieldByRepeatAndComponent(inputFields[currentInputFieldNo],
-					repeat, component, *repeatDelimiter, *componentDelimiter,
-					sliceContainsString(astmTagsList, ANNOTATION_REQUIRED)); err == nil {
-					inputFieldValue = value
-				} else {
-					return errors.New(fmt.Sprintf("Error extracting field '%s' tagged: '%s' : %s ", recordfield.Type().Name(), astmTag, err))
-				}
-
-				if inputFieldValue == "" {
-					reflect.ValueOf(recordFieldInterface).Elem().Set(reflect.ValueOf(time.Time{}))
-				} else if len(inputFieldValue) == 8 { // YYYYMMDD See Section 5.6.2 https://samson-rus.com/wp-content/files/LIS2-A2.pdf
-					timeInLocation, err := time.ParseInLocation("20060102", inputFieldValue, timezone)
-					if err != nil {
-						return errors.New(fmt.Sprintf("Invalid time format <%s>", inputFieldValue))
-					}
-					reflect.ValueOf(recordFieldInterface).Elem().Set(reflect.ValueOf(timeInLocation))
-
-				} else if len(inputFieldValue) == 14 { // YYYYMMDDHHMMSS
-					timeInLocation, err := time.ParseInLocation("20060102150405", inputFieldValue, timezone)
-					if err != nil {
-						return errors.New(fmt.Sprintf("Invalid time format <%s>", inputFieldValue))
-					}
-					reflect.ValueOf(recordFieldInterface).Elem().Set(reflect.ValueOf(timeInLocation.UTC()))
-				} else {
-					return errors.New(fmt.Sprintf("Unrecognized time format <%s>", inputFieldValue))
-				}
-			default:
-				return errors.New(fmt.Sprintf("Invalid type of Field '%s' while trying to unmarshal this string '%s'. This datatype is a structure type which is not implemented.",
-					reflect.TypeOf(recordfield.Interface()).Name(), inputStr))
+			target.Set(reflect.ValueOf(timeInLocation))
+		} else if len(value) == 14 { // YYYYMMDDHHMMSS
+			timeInLocation, err := time.ParseInLocation("20060102150405", value, timezone)
+			if err != nil {
+				return fmt.Errorf("%w: invalid time format <%s>", ErrInvalidValue, value)
 			}
-		default:
-			return errors.New(fmt.Sprintf("Invalid type of Field '%s' while trying to unmarshal this string '%s'. This datatype is not implemented.",
-				reflect.TypeOf(recordfield.Interface()).Kind(), inputStr))
+			target.Set(reflect.ValueOf(timeInLocation.UTC()))
+		} else {
+			return fmt.Errorf("%w: unrecognized time format <%s>", ErrInvalidValue, value)
 		}
+	case kind == reflect.Struct:
+		return fmt.Errorf("%w: the structure type '%s' is not implemented", ErrUnsupportedType, target.Type().Name())
+	default:
+		return fmt.Errorf("%w: the datatype '%s' is not implemented", ErrUnsupportedType, kind)
 	}
-
 	return nil
 }
 
@@ -521,13 +769,14 @@ func readFieldAddressAnnotation(annotation string) (field int, repeat int, compo
 }
 
 // input is an unpacked field from an astm-file free of the field delimiter ("|")
-// this function ettracts the field by repeat and component-delimiter
-func extractAstmFieldByRepeatAndComponent(text string, repeat int, component int, repeatDelimiter, componentDelimiter string, isRequired bool) (string, error) {
+// this function ettracts the field by repeat and component-delimiter and resolves the escape sequences
+func extractAstmFieldByRepeatAndComponent(text string, repeat int, component int, delimiters Delimiters, isRequired bool) (string, error) {
+	repeatDelimiter, componentDelimiter := delimiters.Repeat, delimiters.Component
 
 	subfield := strings.Split(text, repeatDelimiter)
 	if repeat >= len(subfield) {
 		if isRequired {
-			return "", errors.New(fmt.Sprintf("Index (%d, %d) out of bounds '%s', delimiter '%s'", repeat, component, text, repeatDelimiter))
+			return "", fmt.Errorf("%w: index (%d, %d) out of bounds '%s', delimiter '%s'", ErrMissingValue, repeat, component, text, repeatDelimiter)
 		}
 		return "", nil
 	}
@@ -535,12 +784,50 @@ func extractAstmFieldByRepeatAndComponent(text string, repeat int, component int
 	subsubfield := strings.Split(subfield[repeat], componentDelimiter)
 	if component >= len(subsubfield) || component < 0 {
 		if isRequired {
-			return "", errors.New(fmt.Sprintf("Index (%d, %d) out of bounds '%s' delimiter '%s'", repeat, component, text, componentDelimiter))
+			return "", fmt.Errorf("%w: index (%d, %d) out of bounds '%s' delimiter '%s'", ErrMissingValue, repeat, component, text, componentDelimiter)
 		}
 		return "", nil
 	}
 
-	return subsubfield[component], nil
+	return unescapeValue(subsubfield[component], delimiters), nil
+}
+
+// isIntKind tells if the kind is a signed integer of any width
+func isIntKind(kind reflect.Kind) bool {
+	return kind == reflect.Int || kind == reflect.Int8 || kind == reflect.Int16 || kind == reflect.Int32 || kind == reflect.Int64
+}
+
+// isUintKind tells if the kind is an unsigned integer of any width
+func isUintKind(kind reflect.Kind) bool {
+	return kind == reflect.Uint || kind == reflect.Uint8 || kind == reflect.Uint16 || kind == reflect.Uint32 || kind == reflect.Uint64
+}
+
+// boolLiterals returns the values standing for true and false, set by annotations like "true=Y,false=N"
+func boolLiterals(astmTagsList []string) (string, string) {
+	trueLiteral, falseLiteral := "Y", "N"
+	if value, ok := annotationOption(astmTagsList, ANNOTATION_TRUE); ok {
+		trueLiteral = value
+	}
+	if value, ok := annotationOption(astmTagsList, ANNOTATION_FALSE); ok {
+		falseLiteral = value
+	}
+	return trueLiteral, falseLiteral
+}
+
+// hasDecimalComma tells if floats are written with a decimal comma, annotated by "decimal=comma"
+func hasDecimalComma(astmTagsList []string) bool {
+	separator, _ := annotationOption(astmTagsList, ANNOTATION_DECIMAL)
+	return separator == "comma"
+}
+
+// annotationOption returns the value of an annotation with a value, e.g. "Y" for "true=Y"
+func annotationOption(list []string, name string) (string, bool) {
+	for _, x := range list {
+		if strings.HasPrefix(x, name+"=") {
+			return strings.TrimPrefix(x, name+"="), true
+		}
+	}
+	return "", false
 }
 
 func sliceContainsString(list []string, search string) bool {
@@ -0,0 +1,88 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis1a"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+)
+
+// ClientConfig holds the settings for a connection to an instrument acting as TCP server or via serial line
+type ClientConfig struct {
+	Encoding    lis2a2.Encoding
+	Timezone    lis2a2.Timezone
+	Notation    lis2a2.Notation
+	Session     lis1a.SessionConfig
+	DialTimeout time.Duration
+	// Handler receives messages the instrument sends on this connection, they are dropped if nil
+	Handler Handler
+}
+
+var DefaultClientConfig = ClientConfig{
+	Encoding:    lis2a2.EncodingUTF8,
+	Timezone:    lis2a2.TimezoneUTC,
+	Notation:    lis2a2.ShortNotation,
+	Session:     lis1a.SessionConfig{Role: lis1a.RoleComputer},
+	DialTimeout: 10 * time.Second,
+}
+
+// Client pushes messages (e.g. orders) to an instrument
+type Client struct {
+	conn    io.ReadWriteCloser
+	session *lis1a.Session
+	config  ClientConfig
+	done    chan struct{}
+}
+
+// Dial connects to the instrument at the TCP address
+func Dial(addr string, config ClientConfig) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, config.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn, config), nil
+}
+
+// NewClient runs the link layer on an already established connection
+func NewClient(conn net.Conn, config ClientConfig) *Client {
+	return newClient(conn, conn.RemoteAddr(), config)
+}
+
+func newClient(conn io.ReadWriteCloser, remoteAddr net.Addr, config ClientConfig) *Client {
+	client := &Client{
+		conn:   conn,
+		config: config,
+		done:   make(chan struct{}),
+	}
+	client.session = lis1a.NewSession(conn, func(data []byte) {
+		if config.Handler != nil {
+			config.Handler(newMessage(data, remoteAddr, config.Encoding, config.Timezone))
+		}
+	}, config.Session)
+
+	go func() {
+		client.session.Run()
+		close(client.done)
+	}()
+
+	return client
+}
+
+// Send marshals the annotated struct (e.g. standardlis2a2.DefaultMessage) and transmits it.
+// The report lists the acknowledgement of every frame, also when the transmission failed.
+func (c *Client) Send(message interface{}) (lis1a.TransferReport, error) {
+	records, err := lis2a2.Marshal(message, c.config.Encoding, c.config.Timezone, c.config.Notation)
+	if err != nil {
+		return lis1a.TransferReport{}, err
+	}
+	return c.session.Transfer(records)
+}
+
+// Close terminates the connection and waits for the link layer to stop
+func (c *Client) Close() error {
+	err := c.conn.Close()
+	<-c.done
+	return err
+}
@@ -0,0 +1,134 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lib/standardlis2a2"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis1a"
+	"github.com/stretchr/testify/assert"
+)
+
+func readFrame(t *testing.T, conn net.Conn) lis1a.Frame {
+	t.Helper()
+	var raw []byte
+	for len(raw) == 0 || raw[len(raw)-1] != lis1a.LF {
+		raw = append(raw, readByte(t, conn))
+	}
+	frame, err := lis1a.ParseFrame(raw)
+	assert.Nil(t, err)
+	return frame
+}
+
+// instrumentServer accepts one connection and passes it to play
+func instrumentServer(t *testing.T, play func(conn net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		play(conn)
+	}()
+	return listener.Addr().String()
+}
+
+func orderMessage() standardlis2a2.DefaultMessage {
+	var msg standardlis2a2.DefaultMessage
+	msg.Header.SenderNameOrID = "LIS"
+	msg.OrderResults = make([]standardlis2a2.PORC, 1)
+	msg.OrderResults[0].Patient.LabAssignedPatientID = "1010868845"
+	msg.OrderResults[0].Order.SpecimenID = "1122206642"
+	msg.OrderResults[0].Order.UniversalTestID = "MO10"
+	msg.Terminator.TerminatorCode = "N"
+	return msg
+}
+
+func TestClientSendsOrders(t *testing.T) {
+	received := make(chan []string, 1)
+	addr := instrumentServer(t, func(conn net.Conn) {
+		assert.Equal(t, lis1a.ENQ, readByte(t, conn))
+		conn.Write([]byte{lis1a.ACK})
+
+		var records []string
+		nakSent := false
+		for {
+			b := readByte(t, conn)
+			if b == lis1a.EOT {
+				break
+			}
+			assert.Equal(t, lis1a.STX, b)
+			raw := []byte{b}
+			for raw[len(raw)-1] != lis1a.LF {
+				raw = append(raw, readByte(t, conn))
+			}
+			frame, err := lis1a.ParseFrame(raw)
+			assert.Nil(t, err)
+			if frame.Number == 3 && !nakSent { // reject the patient record once
+				nakSent = true
+				conn.Write([]byte{lis1a.NAK})
+				continue
+			}
+			records = append(records, string(frame.Text))
+			conn.Write([]byte{lis1a.ACK})
+		}
+		received <- records
+	})
+
+	client, err := Dial(addr, DefaultClientConfig)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	report, err := client.Send(orderMessage())
+	assert.Nil(t, err)
+
+	assert.Equal(t, 5, len(report.Frames))
+	for i, frame := range report.Frames {
+		assert.True(t, frame.Acknowledged)
+		assert.Equal(t, i+1, frame.Number)
+		if frame.Number == 3 {
+			assert.Equal(t, 2, frame.Attempts)
+		} else {
+			assert.Equal(t, 1, frame.Attempts)
+		}
+	}
+
+	records := <-received
+	assert.Equal(t, 5, len(records))
+	assert.True(t, strings.HasPrefix(records[3], "O|1|1122206642||MO10|"))
+}
+
+func TestClientReportsFailure(t *testing.T) {
+	addr := instrumentServer(t, func(conn net.Conn) {
+		assert.Equal(t, lis1a.ENQ, readByte(t, conn))
+		conn.Write([]byte{lis1a.ACK})
+		readFrame(t, conn)
+		conn.Write([]byte{lis1a.ACK})
+		for i := 0; i < lis1a.MAX_RETRANSMISSIONS; i++ {
+			readFrame(t, conn)
+			conn.Write([]byte{lis1a.NAK})
+		}
+		assert.Equal(t, lis1a.EOT, readByte(t, conn))
+		io.Copy(io.Discard, conn)
+	})
+
+	config := DefaultClientConfig
+	config.Session.Timeouts.Acknowledge = 2 * time.Second
+	client, err := Dial(addr, config)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	report, err := client.Send(orderMessage())
+	assert.True(t, errors.Is(err, lis1a.ErrTooManyRetransmissions))
+	assert.Equal(t, 2, len(report.Frames))
+	assert.True(t, report.Frames[0].Acknowledged)
+	assert.False(t, report.Frames[1].Acknowledged)
+	assert.Equal(t, lis1a.MAX_RETRANSMISSIONS, report.Frames[1].Attempts)
+}
@@ -0,0 +1,90 @@
+package transport
+
+import (
+	"bufio"
+	"io"
+)
+
+// RawScanner splits an unframed stream of records (no LIS1-A, no handshake) into messages.
+// A message starts with an H record and ends with an L record. Records may be terminated by
+// CR, LF or any mix of both, empty lines are skipped.
+type RawScanner struct {
+	reader  *bufio.Reader
+	message []byte
+	err     error
+}
+
+func NewRawScanner(reader io.Reader) *RawScanner {
+	return &RawScanner{reader: bufio.NewReader(reader)}
+}
+
+// Next returns the next complete message with its records separated by CR, ready for lis2a2.Unmarshal.
+// A message that is not terminated by an L record is returned when the next H record or the end of
+// the stream is reached. At the end of the stream Next returns the error of the underlying reader.
+func (s *RawScanner) Next() ([]byte, error) {
+	for {
+		if s.err != nil {
+			if len(s.message) > 0 {
+				return s.flush(), nil
+			}
+			return nil, s.err
+		}
+
+		record, err := s.readRecord()
+		if err != nil {
+			s.err = err
+		}
+		if len(record) == 0 {
+			continue
+		}
+
+		switch record[0] {
+		case 'H':
+			var previous []byte
+			if len(s.message) > 0 {
+				previous = s.flush()
+			}
+			s.append(record)
+			if previous != nil {
+				return previous, nil
+			}
+		case 'L':
+			if len(s.message) == 0 {
+				continue // terminator without a header
+			}
+			s.append(record)
+			return s.flush(), nil
+		default:
+			if len(s.message) == 0 {
+				continue // garbage before the first header
+			}
+			s.append(record)
+		}
+	}
+}
+
+// readRecord reads up to the next CR or LF, partial reads of the underlying stream are joined by bufio
+func (s *RawScanner) readRecord() ([]byte, error) {
+	var record []byte
+	for {
+		b, err := s.reader.ReadByte()
+		if err != nil {
+			return record, err
+		}
+		if b == 0x0D || b == 0x0A {
+			return record, nil
+		}
+		record = append(record, b)
+	}
+}
+
+func (s *RawScanner) append(record []byte) {
+	s.message = append(s.message, record...)
+	s.message = append(s.message, 0x0D)
+}
+
+func (s *RawScanner) flush() []byte {
+	message := s.message
+	s.message = nil
+	return message
+}
@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawScannerSplitsMessages(t *testing.T) {
+	stream := "H|\\^&|||EUROIMMUN Analyzer I\r\n" +
+		"P|1||TEST-27-079-5-1\n" +
+		"O|1|||^^^SARSCOV2IGA\n\r" +
+		"R|1|^^^SARSCOV2IGA|>8|Ratio|\r\r" +
+		"L|1|N\r" +
+		"H|\\^&|||EUROIMMUN Analyzer I\r" +
+		"P|1||TEST-27-077-5-1\r" +
+		"L|1|N"
+
+	// deliver the stream one byte at a time to provoke partial reads
+	scanner := NewRawScanner(iotest.OneByteReader(strings.NewReader(stream)))
+
+	message, err := scanner.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "H|\\^&|||EUROIMMUN Analyzer I\rP|1||TEST-27-079-5-1\rO|1|||^^^SARSCOV2IGA\rR|1|^^^SARSCOV2IGA|>8|Ratio|\rL|1|N\r", string(message))
+
+	message, err = scanner.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "H|\\^&|||EUROIMMUN Analyzer I\rP|1||TEST-27-077-5-1\rL|1|N\r", string(message))
+
+	_, err = scanner.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestRawScannerMessageWithoutTerminator(t *testing.T) {
+	stream := "garbage\rH|\\^&\rP|1||A\rH|\\^&\rP|1||B\r"
+	scanner := NewRawScanner(strings.NewReader(stream))
+
+	message, err := scanner.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "H|\\^&\rP|1||A\r", string(message))
+
+	message, err = scanner.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "H|\\^&\rP|1||B\r", string(message))
+
+	_, err = scanner.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestServerRawMode(t *testing.T) {
+	received := make(chan Message, 2)
+	server := &Server{
+		Handler: func(message Message) { received <- message },
+		Configure: func(conn net.Conn) ConnectionConfig {
+			config := DefaultConnectionConfig
+			config.Mode = ModeRaw
+			return config
+		},
+	}
+	addr := startTestServer(t, server)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", addr)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	for _, record := range resultRecords("TEST-27-079-5-1") {
+		conn.Write(append(record, '\n'))
+	}
+
+	select {
+	case message := <-received:
+		var decoded resultMessage
+		assert.Nil(t, message.Unmarshal(&decoded))
+		assert.Equal(t, "TEST-27-079-5-1", decoded.OrderResults[0].Patient.LabAssignedPatientID)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not received")
+	}
+}
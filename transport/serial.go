@@ -0,0 +1,57 @@
+package transport
+
+import (
+	"errors"
+)
+
+var ErrSerialNotSupported = errors.New("transport: serial ports are not supported on this platform")
+
+type Parity int
+
+const ParityNone Parity = 0
+const ParityOdd Parity = 1
+const ParityEven Parity = 2
+
+type StopBits int
+
+const StopBits1 StopBits = 1
+const StopBits2 StopBits = 2
+
+type FlowControl int
+
+const FlowControlNone FlowControl = 0
+const FlowControlXONXOFF FlowControl = 1 // software flow control
+const FlowControlRTSCTS FlowControl = 2  // hardware flow control
+
+// SerialConfig holds the line settings of a RS-232 connection
+type SerialConfig struct {
+	BaudRate    int
+	DataBits    int // 5-8
+	Parity      Parity
+	StopBits    StopBits
+	FlowControl FlowControl
+}
+
+// DefaultSerialConfig is 9600 baud 8N1 as used by most instruments
+var DefaultSerialConfig = SerialConfig{
+	BaudRate:    9600,
+	DataBits:    8,
+	Parity:      ParityNone,
+	StopBits:    StopBits1,
+	FlowControl: FlowControlNone,
+}
+
+type serialAddr string
+
+func (a serialAddr) Network() string { return "serial" }
+func (a serialAddr) String() string  { return string(a) }
+
+// OpenSerial opens the character device (e.g. /dev/ttyS0) and runs the LIS1-A link layer on it.
+// Messages are sent with the returned client, received messages are passed to config.Handler.
+func OpenSerial(device string, serialConfig SerialConfig, config ClientConfig) (*Client, error) {
+	port, err := OpenSerialPort(device, serialConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(port, serialAddr(device), config), nil
+}
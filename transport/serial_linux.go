@@ -0,0 +1,151 @@
+//go:build linux && (386 || amd64 || arm || arm64 || riscv64 || s390x)
+// +build linux
+// +build 386 amd64 arm arm64 riscv64 s390x
+
+package transport
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// not exported by the syscall package
+const (
+	termiosCBAUD   = 0x100f
+	termiosCRTSCTS = 0x80000000
+)
+
+var baudRates = map[int]uint32{
+	1200:   syscall.B1200,
+	2400:   syscall.B2400,
+	4800:   syscall.B4800,
+	9600:   syscall.B9600,
+	19200:  syscall.B19200,
+	38400:  syscall.B38400,
+	57600:  syscall.B57600,
+	115200: syscall.B115200,
+	230400: syscall.B230400,
+}
+
+var dataBits = map[int]uint32{
+	5: syscall.CS5,
+	6: syscall.CS6,
+	7: syscall.CS7,
+	8: syscall.CS8,
+}
+
+// SerialPort is a character device configured for raw transmission
+type SerialPort struct {
+	file *os.File
+}
+
+// OpenSerialPort opens the device and applies the line settings
+func OpenSerialPort(device string, config SerialConfig) (*SerialPort, error) {
+	file, err := os.OpenFile(device, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	port := &SerialPort{file: file}
+	if err := port.Configure(config); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return port, nil
+}
+
+// Configure applies the line settings; the device is always put into raw mode (no echo,
+// no line editing, no translation of CR and LF) as required for ASTM
+func (p *SerialPort) Configure(config SerialConfig) error {
+	speed, ok := baudRates[config.BaudRate]
+	if !ok {
+		return fmt.Errorf("unsupported baud rate %d", config.BaudRate)
+	}
+	size, ok := dataBits[config.DataBits]
+	if !ok {
+		return fmt.Errorf("unsupported number of data bits %d", config.DataBits)
+	}
+
+	var termios syscall.Termios
+	if err := p.ioctl(syscall.TCGETS, &termios); err != nil {
+		return err
+	}
+
+	termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON | syscall.IXOFF | syscall.IXANY | syscall.INPCK
+	termios.Oflag &^= syscall.OPOST
+	termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Cflag &^= syscall.CSIZE | syscall.PARENB | syscall.PARODD | syscall.CSTOPB | termiosCRTSCTS | termiosCBAUD
+	termios.Cflag |= syscall.CREAD | syscall.CLOCAL | size | speed
+	termios.Ispeed = speed
+	termios.Ospeed = speed
+
+	switch config.Parity {
+	case ParityNone:
+	case ParityOdd:
+		termios.Cflag |= syscall.PARENB | syscall.PARODD
+		termios.Iflag |= syscall.INPCK
+	case ParityEven:
+		termios.Cflag |= syscall.PARENB
+		termios.Iflag |= syscall.INPCK
+	default:
+		return fmt.Errorf("unsupported parity %d", config.Parity)
+	}
+
+	switch config.StopBits {
+	case StopBits1:
+	case StopBits2:
+		termios.Cflag |= syscall.CSTOPB
+	default:
+		return fmt.Errorf("unsupported number of stop bits %d", config.StopBits)
+	}
+
+	switch config.FlowControl {
+	case FlowControlNone:
+	case FlowControlXONXOFF:
+		termios.Iflag |= syscall.IXON | syscall.IXOFF
+	case FlowControlRTSCTS:
+		termios.Cflag |= termiosCRTSCTS
+	default:
+		return fmt.Errorf("unsupported flow control %d", config.FlowControl)
+	}
+
+	// read returns as soon as one byte is available
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+
+	return p.ioctl(syscall.TCSETS, &termios)
+}
+
+func (p *SerialPort) ioctl(request uintptr, termios *syscall.Termios) error {
+	conn, err := p.file.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(termios)))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (p *SerialPort) Read(b []byte) (int, error) {
+	return p.file.Read(b)
+}
+
+func (p *SerialPort) Write(b []byte) (int, error) {
+	return p.file.Write(b)
+}
+
+// Close closes the device, a pending Read returns with an error
+func (p *SerialPort) Close() error {
+	return p.file.Close()
+}
@@ -0,0 +1,129 @@
+//go:build linux && (386 || amd64 || arm || arm64 || riscv64 || s390x)
+// +build linux
+// +build 386 amd64 arm arm64 riscv64 s390x
+
+package transport
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis1a"
+	"github.com/stretchr/testify/assert"
+)
+
+// openPty returns the master side and the device name of the slave side of a new pseudo-terminal
+func openPty(t *testing.T) (*os.File, string) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals not available: %s", err)
+	}
+
+	conn, err := master.SyscallConn()
+	assert.Nil(t, err)
+
+	var number uint32
+	var unlock int32
+	var errno syscall.Errno
+	conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock)))
+		if errno == 0 {
+			_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&number)))
+		}
+	})
+	if errno != 0 {
+		master.Close()
+		t.Skipf("pseudo-terminals not available: %s", errno)
+	}
+
+	return master, fmt.Sprintf("/dev/pts/%d", number)
+}
+
+func TestSerialReceivesFromInstrument(t *testing.T) {
+	master, device := openPty(t)
+	defer master.Close()
+
+	received := make(chan Message, 1)
+	config := DefaultClientConfig
+	config.Handler = func(message Message) { received <- message }
+
+	serialConfig := DefaultSerialConfig
+	serialConfig.BaudRate = 19200
+	serialConfig.Parity = ParityEven
+	serialConfig.DataBits = 7
+
+	client, err := OpenSerial(device, serialConfig, config)
+	if err != nil {
+		t.Skipf("can not open %s: %s", device, err)
+	}
+	defer client.Close()
+
+	sendAsInstrument(t, master, resultRecords("TEST-27-079-5-1"))
+
+	select {
+	case message := <-received:
+		assert.Equal(t, device, message.RemoteAddr.String())
+		var decoded resultMessage
+		assert.Nil(t, message.Unmarshal(&decoded))
+		assert.Equal(t, "TEST-27-079-5-1", decoded.OrderResults[0].Patient.LabAssignedPatientID)
+		assert.Equal(t, "6,77", decoded.OrderResults[0].Result[0].DataMeasurementValue)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not received")
+	}
+}
+
+func TestSerialSendsToInstrument(t *testing.T) {
+	master, device := openPty(t)
+	defer master.Close()
+
+	client, err := OpenSerial(device, DefaultSerialConfig, DefaultClientConfig)
+	if err != nil {
+		t.Skipf("can not open %s: %s", device, err)
+	}
+	defer client.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.Send(orderMessage())
+		result <- err
+	}()
+
+	assert.Equal(t, lis1a.ENQ, readByte(t, master))
+	master.Write([]byte{lis1a.ACK})
+
+	var records []string
+	for {
+		b := readByte(t, master)
+		if b == lis1a.EOT {
+			break
+		}
+		raw := []byte{b}
+		for raw[len(raw)-1] != lis1a.LF {
+			raw = append(raw, readByte(t, master))
+		}
+		frame, err := lis1a.ParseFrame(raw)
+		assert.Nil(t, err)
+		records = append(records, string(frame.Text))
+		master.Write([]byte{lis1a.ACK})
+	}
+
+	assert.Nil(t, <-result)
+	assert.Equal(t, 5, len(records))
+	assert.True(t, strings.HasPrefix(records[0], "H|\\^&"))
+	assert.True(t, strings.HasPrefix(records[3], "O|1|1122206642"))
+}
+
+func TestSerialRejectsInvalidSettings(t *testing.T) {
+	master, device := openPty(t)
+	defer master.Close()
+
+	serialConfig := DefaultSerialConfig
+	serialConfig.BaudRate = 12345
+	_, err := OpenSerialPort(device, serialConfig)
+	assert.NotNil(t, err)
+}
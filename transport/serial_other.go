@@ -0,0 +1,23 @@
+//go:build !linux || !(386 || amd64 || arm || arm64 || riscv64 || s390x)
+// +build !linux !386,!amd64,!arm,!arm64,!riscv64,!s390x
+
+package transport
+
+// SerialPort is only implemented for linux
+type SerialPort struct{}
+
+func OpenSerialPort(device string, config SerialConfig) (*SerialPort, error) {
+	return nil, ErrSerialNotSupported
+}
+
+func (p *SerialPort) Read(b []byte) (int, error) {
+	return 0, ErrSerialNotSupported
+}
+
+func (p *SerialPort) Write(b []byte) (int, error) {
+	return 0, ErrSerialNotSupported
+}
+
+func (p *SerialPort) Close() error {
+	return nil
+}
@@ -0,0 +1,204 @@
+package transport
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis1a"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+)
+
+var ErrServerClosed = errors.New("transport: server closed")
+
+// Message is one complete transmission received from an instrument
+type Message struct {
+	Data       []byte             // records separated by CR, still in the encoding of the instrument
+	Type       lis2a2.MessageType // as identified by lis2a2.IdentifyMessage
+	Encoding   lis2a2.Encoding
+	Timezone   lis2a2.Timezone
+	RemoteAddr net.Addr
+	ReceivedAt time.Time
+}
+
+// Unmarshal decodes the message with the encoding and timezone of its connection
+func (m Message) Unmarshal(targetStruct interface{}) error {
+	return lis2a2.Unmarshal(m.Data, targetStruct, m.Encoding, m.Timezone)
+}
+
+// Handler is called for every received message. It is called concurrently for different
+// connections and blocks the link of its own connection while running.
+type Handler func(message Message)
+
+// Mode selects how messages are transported on a connection
+type Mode int
+
+const ModeLIS1A Mode = 1 // framed with ENQ/ACK handshake (default)
+const ModeRaw Mode = 2   // plain records without framing or handshake, messages are delimited by H and L records
+
+// ConnectionConfig holds the settings of one instrument connection
+type ConnectionConfig struct {
+	Mode     Mode
+	Encoding lis2a2.Encoding
+	Timezone lis2a2.Timezone
+	Session  lis1a.SessionConfig // not used in ModeRaw
+}
+
+var DefaultConnectionConfig = ConnectionConfig{
+	Mode:     ModeLIS1A,
+	Encoding: lis2a2.EncodingUTF8,
+	Timezone: lis2a2.TimezoneUTC,
+	Session:  lis1a.SessionConfig{Role: lis1a.RoleComputer},
+}
+
+// Server accepts connections from instruments acting as TCP clients and runs
+// the LIS1-A link layer for each of them
+type Server struct {
+	Addr    string
+	Handler Handler
+	// Configure returns the settings for a newly accepted connection, e.g. depending on the
+	// remote address. If nil, DefaultConnectionConfig is used for all connections.
+	Configure func(conn net.Conn) ConnectionConfig
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// ListenAndServe listens on the TCP address s.Addr and serves the accepted connections
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on the listener until Close is called. It always returns a non-nil error,
+// after Close the error is ErrServerClosed.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listener = listener
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]struct{})
+	}
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return ErrServerClosed
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serveConnection(conn)
+	}
+}
+
+func (s *Server) serveConnection(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		s.wg.Done()
+	}()
+
+	config := DefaultConnectionConfig
+	if s.Configure != nil {
+		config = s.Configure(conn)
+	}
+
+	if config.Mode == ModeRaw {
+		scanner := NewRawScanner(conn)
+		for {
+			data, err := scanner.Next()
+			if err != nil {
+				return
+			}
+			s.deliver(data, conn.RemoteAddr(), config)
+		}
+	}
+
+	session := lis1a.NewSession(conn, func(data []byte) {
+		s.deliver(data, conn.RemoteAddr(), config)
+	}, config.Session)
+	session.Run()
+}
+
+func (s *Server) deliver(data []byte, remoteAddr net.Addr, config ConnectionConfig) {
+	if s.Handler == nil {
+		return
+	}
+	s.Handler(newMessage(data, remoteAddr, config.Encoding, config.Timezone))
+}
+
+func newMessage(data []byte, remoteAddr net.Addr, enc lis2a2.Encoding, tz lis2a2.Timezone) Message {
+	messageType, err := lis2a2.IdentifyMessage(data, enc)
+	if err != nil {
+		messageType = lis2a2.MessageTypeUnkown
+	}
+
+	return Message{
+		Data:       data,
+		Type:       messageType,
+		Encoding:   enc,
+		Timezone:   tz,
+		RemoteAddr: remoteAddr,
+		ReceivedAt: time.Now(),
+	}
+}
+
+// ListenerAddr returns the address the server is listening on, nil before Serve was called
+func (s *Server) ListenerAddr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close stops accepting connections, closes all open connections and waits for their handlers to return
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
@@ -0,0 +1,169 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lib/standardlis2a2"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis1a"
+	"github.com/DRK-Blutspende-BaWueHe/go-astm/lis2a2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+)
+
+type resultMessage struct {
+	Header       standardlis2a2.Header `astm:"H"`
+	OrderResults []struct {
+		Patient standardlis2a2.Patient  `astm:"P"`
+		Order   standardlis2a2.Order    `astm:"O"`
+		Result  []standardlis2a2.Result `astm:"R"`
+	}
+	Terminator standardlis2a2.Terminator `astm:"L"`
+}
+
+func resultRecords(patientID string) [][]byte {
+	return [][]byte{
+		[]byte("H|\\^&|||EUROIMMUN Analyzer I|||||||P|LIS2-A2|20220218080737"),
+		[]byte("P|1||" + patientID),
+		[]byte("O|1|||^^^SARSCOV2IGA||20220218080737"),
+		[]byte("R|1|^^^SARSCOV2IGA|6,77|Ratio|"),
+		[]byte("L|1|N"),
+	}
+}
+
+func readByte(t *testing.T, conn io.Reader) byte {
+	t.Helper()
+	if c, ok := conn.(interface{ SetReadDeadline(time.Time) error }); ok {
+		c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	}
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(conn, b); err != nil {
+		t.Fatalf("reading from connection: %s", err)
+	}
+	return b[0]
+}
+
+// sendAsInstrument plays the sending side of LIS1-A
+func sendAsInstrument(t *testing.T, conn io.ReadWriter, records [][]byte) {
+	t.Helper()
+	conn.Write([]byte{lis1a.ENQ})
+	assert.Equal(t, lis1a.ACK, readByte(t, conn))
+	for _, frame := range lis1a.EncodeRecords(records) {
+		conn.Write(frame)
+		assert.Equal(t, lis1a.ACK, readByte(t, conn))
+	}
+	conn.Write([]byte{lis1a.EOT})
+}
+
+func startTestServer(t *testing.T, server *Server) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go server.Serve(listener)
+	return listener.Addr().String()
+}
+
+func TestServerReceivesFromMultipleInstruments(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string]Message)
+	done := make(chan struct{}, 10)
+
+	server := &Server{Handler: func(message Message) {
+		var decoded resultMessage
+		err := message.Unmarshal(&decoded)
+		assert.Nil(t, err)
+
+		mu.Lock()
+		received[decoded.OrderResults[0].Patient.LabAssignedPatientID] = message
+		mu.Unlock()
+		done <- struct{}{}
+	}}
+	addr := startTestServer(t, server)
+	defer server.Close()
+
+	const instruments = 5
+	var wg sync.WaitGroup
+	for i := 0; i < instruments; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, err := net.Dial("tcp", addr)
+			assert.Nil(t, err)
+			defer conn.Close()
+			sendAsInstrument(t, conn, resultRecords(fmt.Sprintf("TEST-27-079-5-%d", i)))
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < instruments; i++ {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("not all messages were received")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, instruments, len(received))
+	for _, message := range received {
+		assert.Equal(t, lis2a2.MessageTypeOrdersAndResults, message.Type)
+		assert.NotNil(t, message.RemoteAddr)
+	}
+}
+
+func TestServerUsesConnectionEncoding(t *testing.T) {
+	received := make(chan Message, 1)
+	server := &Server{
+		Handler: func(message Message) { received <- message },
+		Configure: func(conn net.Conn) ConnectionConfig {
+			config := DefaultConnectionConfig
+			config.Encoding = lis2a2.EncodingWindows1252
+			config.Timezone = lis2a2.TimezoneEuropeBerlin
+			return config
+		},
+	}
+	addr := startTestServer(t, server)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", addr)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	records := resultRecords("1010868845")
+	records[1], _ = charmap.Windows1252.NewEncoder().Bytes([]byte("P|1||1010868845||König^Jürgen"))
+	sendAsInstrument(t, conn, records)
+
+	select {
+	case message := <-received:
+		assert.Equal(t, lis2a2.EncodingWindows1252, message.Encoding)
+		var decoded resultMessage
+		assert.Nil(t, message.Unmarshal(&decoded))
+		assert.Equal(t, "König", decoded.OrderResults[0].Patient.LastName)
+		assert.Equal(t, "Jürgen", decoded.OrderResults[0].Patient.FirstName)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not received")
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	server := &Server{}
+	addr := startTestServer(t, server)
+
+	conn, err := net.Dial("tcp", addr)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	// wait for the connection to be accepted
+	conn.Write([]byte{lis1a.ENQ})
+	assert.Equal(t, lis1a.ACK, readByte(t, conn))
+
+	assert.Nil(t, server.Close())
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+}